@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/anilcse/cosmoscope/internal/config"
+	"github.com/anilcse/cosmoscope/internal/portfolio"
+)
+
+// runAddress reports a single address instead of the configured accounts.
+// Bech32 addresses are queried on every configured Cosmos network and 0x
+// addresses on every configured EVM network.
+func runAddress(args []string) {
+	flags := flag.NewFlagSet("address", flag.ExitOnError)
+	opts := addGlobalFlags(flags)
+	filters := addFilterFlags(flags)
+	output := flags.String("output", portfolio.OutputTable, "report format: table, json, csv or ndjson")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: cosmoscope address [flags] <address>")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	filter := filters.filter()
+	cfg := opts.load()
+
+	address := flags.Arg(0)
+	cfg.CosmosAddresses, cfg.EVMAddresses = nil, nil
+	cfg.ValidatorAddresses, cfg.FixedBalances = nil, nil
+	if strings.HasPrefix(address, "0x") {
+		cfg.EVMAddresses = []config.Address{{Address: address}}
+	} else {
+		cfg.CosmosAddresses = []config.Address{{Address: address}}
+	}
+
+	report(cfg, filter, *output)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/anilcse/cosmoscope/internal/alert"
+	"github.com/anilcse/cosmoscope/internal/config"
+	"github.com/anilcse/cosmoscope/internal/portfolio"
+	"github.com/anilcse/cosmoscope/internal/store"
+	"github.com/fatih/color"
+)
+
+// checkAlerts evaluates the alert rules of cfg against balances, prints the
+// alerts that fire to stderr and delivers them to the configured sinks.
+// Alerts whose key is in active are not delivered again; active is updated
+// to the alerts that fired, so a nil map delivers everything.
+func checkAlerts(cfg config.Config, previous *portfolio.Snapshot, balances []portfolio.Balance, active map[string]bool) {
+	if len(cfg.Alerts.Rules) == 0 {
+		return
+	}
+
+	var fresh []alert.Alert
+	fired := alert.Evaluate(cfg.Alerts.Rules, previous, balances)
+	for _, a := range fired {
+		if !active[a.Key()] {
+			fresh = append(fresh, a)
+		}
+	}
+	if active != nil {
+		for key := range active {
+			delete(active, key)
+		}
+		for _, a := range fired {
+			active[a.Key()] = true
+		}
+	}
+	if len(fresh) == 0 {
+		return
+	}
+
+	for _, a := range fresh {
+		fmt.Fprintf(os.Stderr, "%s [%s] %s\n", color.RedString("ALERT"), a.Rule, a.Message)
+	}
+
+	sinks, err := alert.NewSinks(cfg.Alerts.Sinks)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error configuring alert sinks: %v\n", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := alert.Notify(ctx, sinks, fresh); err != nil {
+		fmt.Fprintf(os.Stderr, "Error %v\n", err)
+	}
+}
+
+// latestSnapshot returns the most recent stored snapshot, or nil when there
+// is none or the store cannot be read.
+func latestSnapshot(cfg config.Config) *portfolio.Snapshot {
+	db, err := store.Open(snapshotPath(cfg))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening snapshot store: %v\n", err)
+		return nil
+	}
+	defer db.Close()
+
+	snapshots, err := db.List(1)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading snapshots: %v\n", err)
+		return nil
+	}
+	if len(snapshots) == 0 {
+		return nil
+	}
+	return &snapshots[0]
+}
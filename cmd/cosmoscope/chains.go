@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/anilcse/cosmoscope/internal/cosmos"
+	"github.com/olekukonko/tablewriter"
+)
+
+func runChains(args []string) {
+	flags := flag.NewFlagSet("chains", flag.ExitOnError)
+	opts := addGlobalFlags(flags)
+	_ = flags.Parse(args)
+
+	cfg := opts.load()
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Network", "Type", "Chain ID", "Prefix / Token", "Endpoint"})
+	table.SetAutoMergeCells(false)
+	table.SetRowLine(true)
+
+	for _, networkName := range cfg.CosmosNetworks {
+		chainInfo, err := cosmos.FetchChainInfo(networkName)
+		if err != nil {
+			table.Append([]string{networkName, "cosmos", "-", "-", fmt.Sprintf("error: %v", err)})
+			continue
+		}
+
+		endpoint, err := cosmos.ActiveEndpoint(networkName)
+		if err != nil {
+			endpoint = fmt.Sprintf("error: %v", err)
+		}
+		table.Append([]string{networkName, "cosmos", chainInfo.ChainID, chainInfo.Bech32Prefix, endpoint})
+	}
+
+	for _, network := range cfg.EVMNetworks {
+		table.Append([]string{network.Name, "evm", fmt.Sprint(network.ChainID), network.NativeToken.Symbol, network.RPC})
+	}
+
+	table.Render()
+}
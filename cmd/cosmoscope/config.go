@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/anilcse/cosmoscope/internal/config"
+	"github.com/anilcse/cosmoscope/internal/cosmos"
+	"github.com/anilcse/cosmoscope/internal/evm"
+)
+
+func runConfig(args []string) {
+	if len(args) == 0 || args[0] != "validate" {
+		fmt.Fprintln(os.Stderr, "Usage: cosmoscope config validate [flags]")
+		os.Exit(2)
+	}
+
+	flags := flag.NewFlagSet("config validate", flag.ExitOnError)
+	opts := addGlobalFlags(flags)
+	offline := flags.Bool("offline", false, "skip the chain registry and RPC checks")
+	_ = flags.Parse(args[1:])
+
+	validator := config.Validator{IndexerChain: evm.IndexerChain}
+	if !*offline {
+		validator.CheckChain = func(name string) error {
+			_, err := cosmos.FetchChainInfo(name)
+			return err
+		}
+		validator.RPCChainID = evm.ChainID
+	}
+
+	problems, err := validator.Validate(opts.configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	errorCount := 0
+	for _, problem := range problems {
+		if problem.Line > 0 {
+			fmt.Printf("%s:%d: %s\n", opts.configPath, problem.Line, problem)
+		} else {
+			fmt.Printf("%s: %s\n", opts.configPath, problem)
+		}
+		if !problem.Warning {
+			errorCount++
+		}
+	}
+
+	if errorCount > 0 {
+		fmt.Printf("%d problem(s) found\n", errorCount)
+		os.Exit(1)
+	}
+	fmt.Printf("%s: OK\n", opts.configPath)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/anilcse/cosmoscope/internal/metrics"
+	"github.com/anilcse/cosmoscope/internal/price"
+)
+
+// runExporter serves the portfolio as Prometheus metrics. Balances are
+// refreshed in the background so scrapes never wait on chain queries.
+func runExporter(args []string) {
+	flags := flag.NewFlagSet("exporter", flag.ExitOnError)
+	opts := addGlobalFlags(flags)
+	filters := addFilterFlags(flags)
+	listen := flags.String("listen", ":9464", "address to serve /metrics on")
+	interval := flags.Duration("interval", 5*time.Minute, "time between balance refreshes")
+	priceInterval := flags.Duration("price-interval", 15*time.Minute, "time between price refreshes")
+	_ = flags.Parse(args)
+
+	if *interval <= 0 || *priceInterval <= 0 {
+		fmt.Fprintln(os.Stderr, "-interval and -price-interval must be positive")
+		os.Exit(2)
+	}
+
+	filter := filters.filter()
+	cfg := opts.load()
+
+	priceProviders, err := price.NewProviders(cfg.PriceProviders, cfg.CoinGeckoURI)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error configuring price providers: %v\n", err)
+		os.Exit(1)
+	}
+	loadAssetOverrides(cfg)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	server := &http.Server{Addr: *listen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		fmt.Fprintf(os.Stderr, "Serving metrics on %s/metrics\n", *listen)
+		if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	go func() {
+		ticker := time.NewTicker(*interval)
+		defer ticker.Stop()
+
+		refresher := newRefresher(cfg, filter, priceProviders, *priceInterval)
+		for {
+			started := time.Now()
+			// Failed queries are already counted in the failure metrics.
+			balances, tokens, _ := refresher.refresh()
+			metrics.SetBalances(balances)
+			metrics.SetPrices(tokens)
+			metrics.ObserveRefresh(started)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	select {
+	case err := <-serverErr:
+		fmt.Fprintf(os.Stderr, "Error serving metrics: %v\n", err)
+		os.Exit(1)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		fmt.Fprintf(os.Stderr, "Error stopping metrics server: %v\n", err)
+	}
+}
@@ -1,76 +1,134 @@
 package main
 
 import (
+	"flag"
 	"fmt"
-	"sync"
+	"os"
+	"strings"
 
 	"github.com/anilcse/cosmoscope/internal/config"
-	"github.com/anilcse/cosmoscope/internal/cosmos"
-	"github.com/anilcse/cosmoscope/internal/evm"
 	"github.com/anilcse/cosmoscope/internal/portfolio"
-	"github.com/anilcse/cosmoscope/internal/price"
 	"github.com/anilcse/cosmoscope/pkg/utils"
+	"github.com/fatih/color"
 )
 
+type command struct {
+	name  string
+	usage string
+	run   func(args []string)
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"scan", "query every configured account and print the portfolio report (default)", runScan},
+		{"address", "report the balances of a single address", runAddress},
+		{"watch", "refresh the report on an interval", runWatch},
+		{"exporter", "serve balances and prices as Prometheus metrics", runExporter},
+		{"prices", "print the prices of held tokens or of the given CoinGecko IDs", runPrices},
+		{"chains", "list the configured networks and their endpoints", runChains},
+		{"history", "show portfolio value over stored snapshots", runHistory},
+		{"diff", "compare two stored snapshots", runDiff},
+		{"config", "configuration commands (validate)", runConfig},
+	}
+}
+
 func main() {
-	portfolio.PrintHeader()
+	args := os.Args[1:]
+	name := "scan"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
 
-	// Load configuration
-	cfg := config.Load()
+	if name == "help" {
+		usage()
+		return
+	}
+	for _, cmd := range commands {
+		if cmd.name == name {
+			cmd.run(args)
+			return
+		}
+	}
 
-	// Initialize price and IBC data
-	price.InitializePrices(cfg.CoinGeckoURI)
+	fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", name)
+	usage()
+	os.Exit(2)
+}
 
-	// Create channels for collecting balances
-	balanceChan := make(chan portfolio.Balance, 1000)
-	var wg sync.WaitGroup
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: cosmoscope [command] [flags]")
+	fmt.Fprintln(os.Stderr, "\nCommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", cmd.name, cmd.usage)
+	}
+	fmt.Fprintln(os.Stderr, "\nRun 'cosmoscope <command> -h' for the flags of a command.")
+}
 
-	// Add fixed balances
-	portfolio.AddFixedBalances(balanceChan)
+// globalOptions are accepted by every command.
+type globalOptions struct {
+	configPath string
+	noColor    bool
+}
 
-	// Query Cosmos networks
-	for _, networkName := range cfg.CosmosNetworks {
-		chainInfo, err := cosmos.FetchChainInfo(networkName)
-		if err != nil {
-			fmt.Printf("Error fetching chain info for %s: %v\n", networkName, err)
-			continue
-		}
+func addGlobalFlags(flags *flag.FlagSet) *globalOptions {
+	opts := &globalOptions{}
+	flags.StringVar(&opts.configPath, "config", config.DefaultPath, "path to the configuration file")
+	flags.BoolVar(&opts.noColor, "no-color", false, "disable colored output")
+	return opts
+}
 
-		for _, address := range cfg.CosmosAddresses {
-			networkAddress, err := utils.ConvertCosmosAddress(address, chainInfo.Bech32Prefix)
-			if err != nil {
-				fmt.Printf("Error converting address for %s: %v\n", networkName, err)
-				continue
-			}
-
-			wg.Add(1)
-			go func(network, addr string) {
-				defer wg.Done()
-				cosmos.QueryBalances(network, addr, balanceChan)
-			}(networkName, networkAddress)
-		}
+// load applies the global options and loads the configuration, exiting
+// when it cannot be read.
+func (opts *globalOptions) load() config.Config {
+	if opts.noColor {
+		color.NoColor = true
 	}
 
-	// Query EVM networks
-	for _, network := range cfg.EVMNetworks {
-		for _, address := range cfg.EVMAddresses {
-			wg.Add(1)
-			go func(net config.EVMNetwork, addr string) {
-				defer wg.Done()
-				evm.QueryBalances(net, addr, balanceChan)
-			}(network, address)
-		}
+	cfg, err := config.Load(opts.configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+		os.Exit(1)
 	}
+	return cfg
+}
 
-	// Close channel after all goroutines complete
-	go func() {
-		wg.Wait()
-		close(balanceChan)
-	}()
+type filterOptions struct {
+	network string
+	account string
+	token   string
+	owner   string
+	tag     string
+	minUSD  string
+}
 
-	// Collect and display balances
-	balances := portfolio.CollectBalances(balanceChan)
+func addFilterFlags(flags *flag.FlagSet) *filterOptions {
+	opts := &filterOptions{}
+	flags.StringVar(&opts.network, "network", "", "only report this network (e.g. osmosis or osmosis-staking)")
+	flags.StringVar(&opts.account, "account", "", "only report this account (address or label)")
+	flags.StringVar(&opts.token, "token", "", "only report this token symbol")
+	flags.StringVar(&opts.owner, "owner", "", "only report accounts with this owner")
+	flags.StringVar(&opts.tag, "tag", "", "only report accounts with this tag")
+	flags.StringVar(&opts.minUSD, "min-usd", "", "only report balances worth at least this many USD")
+	return opts
+}
 
-	// Print the report
-	portfolio.PrintBalanceReport(balances)
+func (opts *filterOptions) filter() portfolio.Filter {
+	filter := portfolio.Filter{
+		Network: opts.network,
+		Account: opts.account,
+		Token:   opts.token,
+		Owner:   opts.owner,
+		Tag:     opts.tag,
+	}
+	if opts.minUSD != "" {
+		minUSD, err := utils.ParseDecimal(opts.minUSD)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid -min-usd value: %v\n", err)
+			os.Exit(2)
+		}
+		filter.MinUSD = minUSD
+	}
+	return filter
 }
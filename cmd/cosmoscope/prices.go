@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/anilcse/cosmoscope/internal/portfolio"
+	"github.com/anilcse/cosmoscope/internal/price"
+	"github.com/olekukonko/tablewriter"
+)
+
+// runPrices prints prices from the configured provider chain. Arguments are
+// CoinGecko IDs; without arguments the tokens currently held are priced.
+func runPrices(args []string) {
+	flags := flag.NewFlagSet("prices", flag.ExitOnError)
+	opts := addGlobalFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: cosmoscope prices [flags] [coingecko-id...]")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	cfg := opts.load()
+
+	var tokens []price.Token
+	if flags.NArg() > 0 {
+		providers, err := price.NewProviders(cfg.PriceProviders, cfg.CoinGeckoURI)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error configuring price providers: %v\n", err)
+			os.Exit(1)
+		}
+		for _, id := range flags.Args() {
+			tokens = append(tokens, price.Token{ID: strings.ToLower(id), Symbol: strings.ToUpper(id)})
+		}
+		if err := price.InitializePrices(providers, tokens); err != nil {
+			fmt.Fprintf(os.Stderr, "Error %v\n", err)
+		}
+	} else {
+		balances, _, err := scan(cfg, portfolio.Filter{})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		seen := make(map[string]bool)
+		for _, token := range portfolio.PriceTokens(balances) {
+			if !seen[token.Key()] {
+				seen[token.Key()] = true
+				tokens = append(tokens, token)
+			}
+		}
+	}
+
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].Symbol < tokens[j].Symbol
+	})
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Token", "CoinGecko ID", "USD Price", "Source"})
+	table.SetAutoMergeCells(false)
+	table.SetRowLine(true)
+
+	for _, token := range tokens {
+		priceUSD, source, ok := price.Lookup(token)
+		value := "-"
+		if ok {
+			value = fmt.Sprintf("$%.6f", priceUSD)
+		}
+		table.Append([]string{token.Symbol, token.ID, value, source})
+	}
+
+	table.Render()
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/anilcse/cosmoscope/internal/config"
+	"github.com/anilcse/cosmoscope/internal/metrics"
+	"github.com/anilcse/cosmoscope/internal/portfolio"
+	"github.com/anilcse/cosmoscope/internal/price"
+)
+
+// refresher re-queries balances on every call and refreshes prices on their
+// own, usually longer, schedule. It is shared by the scan, watch and
+// exporter commands.
+type refresher struct {
+	cfg           config.Config
+	filter        portfolio.Filter
+	providers     []price.Provider
+	priceInterval time.Duration
+
+	lastPriced time.Time
+	priced     map[string]bool
+}
+
+func newRefresher(cfg config.Config, filter portfolio.Filter, providers []price.Provider, priceInterval time.Duration) *refresher {
+	return &refresher{
+		cfg:           cfg,
+		filter:        filter,
+		providers:     providers,
+		priceInterval: priceInterval,
+		priced:        make(map[string]bool),
+	}
+}
+
+// refresh returns the current priced balances and the tokens they hold. The
+// error is set when a query or the price refresh failed, so the balances
+// may be incomplete or priced with old quotes.
+func (r *refresher) refresh() ([]portfolio.Balance, []price.Token, error) {
+	failures := metrics.Failures()
+	balances := collect(r.cfg, r.filter)
+
+	var errs []string
+	if failed := metrics.Failures() - failures; failed > 0 {
+		errs = append(errs, fmt.Sprintf("%d balance queries failed", failed))
+	}
+
+	// A token that was not part of the last price refresh triggers an
+	// early one. A failed price refresh is retried on the next call.
+	tokens := portfolio.PriceTokens(balances)
+	if time.Since(r.lastPriced) >= r.priceInterval || hasNewTokens(tokens, r.priced) {
+		if err := price.InitializePrices(r.providers, tokens); err != nil {
+			fmt.Fprintf(os.Stderr, "Error %v\n", err)
+			errs = append(errs, "the price refresh failed")
+		} else {
+			r.lastPriced = time.Now()
+			r.priced = make(map[string]bool)
+			for _, token := range tokens {
+				r.priced[token.Key()] = true
+			}
+		}
+	}
+
+	var err error
+	if len(errs) > 0 {
+		err = fmt.Errorf("%s", strings.Join(errs, " and "))
+	}
+	return value(balances, r.filter), tokens, err
+}
+
+func hasNewTokens(tokens []price.Token, priced map[string]bool) bool {
+	for _, token := range tokens {
+		if !priced[token.Key()] {
+			return true
+		}
+	}
+	return false
+}
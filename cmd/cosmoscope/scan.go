@@ -0,0 +1,233 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/anilcse/cosmoscope/internal/config"
+	"github.com/anilcse/cosmoscope/internal/cosmos"
+	"github.com/anilcse/cosmoscope/internal/evm"
+	"github.com/anilcse/cosmoscope/internal/portfolio"
+	"github.com/anilcse/cosmoscope/internal/price"
+	"github.com/anilcse/cosmoscope/internal/store"
+	"github.com/anilcse/cosmoscope/pkg/utils"
+	"github.com/fatih/color"
+)
+
+func runScan(args []string) {
+	flags := flag.NewFlagSet("scan", flag.ExitOnError)
+	opts := addGlobalFlags(flags)
+	filters := addFilterFlags(flags)
+	output := flags.String("output", portfolio.OutputTable, "report format: table, json, csv or ndjson")
+	_ = flags.Parse(args)
+
+	filter := filters.filter()
+	cfg := opts.load()
+	balances, incomplete, ok := report(cfg, filter, *output)
+	if !ok {
+		return
+	}
+
+	// A filtered or incomplete scan is only part of the portfolio, so it
+	// is neither checked for alerts nor kept as a snapshot.
+	if filter.IsZero() {
+		if incomplete != nil {
+			fmt.Fprintf(os.Stderr, "Skipping alerts and snapshot because %v\n", incomplete)
+			return
+		}
+		checkAlerts(cfg, latestSnapshot(cfg), balances, nil)
+		saveSnapshot(cfg, portfolio.NewSnapshot(balances))
+	}
+}
+
+// report scans, filters and prints the portfolio described by cfg. incomplete
+// is set when a query or the price refresh failed.
+func report(cfg config.Config, filter portfolio.Filter, output string) (balances []portfolio.Balance, incomplete error, ok bool) {
+	if !portfolio.ValidOutput(output) {
+		fmt.Fprintf(os.Stderr, "Unknown output format %q\n", output)
+		os.Exit(2)
+	}
+
+	if output == portfolio.OutputTable {
+		portfolio.PrintHeader()
+	} else {
+		color.NoColor = true
+	}
+
+	balances, incomplete, err := scan(cfg, filter)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return nil, nil, false
+	}
+
+	// Print the report
+	filtered := evm.FilteredTokens()
+	if output == portfolio.OutputTable {
+		portfolio.PrintBalanceReport(balances, filtered)
+	} else {
+		if err := portfolio.WriteReport(os.Stdout, output, balances, filtered); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing report: %v\n", err)
+		}
+	}
+
+	if unresolved := cosmos.UnresolvedDenoms(); len(unresolved) > 0 {
+		fmt.Fprintf(os.Stderr, "Unresolved denoms (%d): %s\n", len(unresolved), strings.Join(unresolved, ", "))
+	}
+	printMoralisUsage()
+	return balances, incomplete, true
+}
+
+// printMoralisUsage writes the Moralis API requests made so far to stderr.
+func printMoralisUsage() {
+	if usage := evm.MoralisCalls(); usage.Calls > 0 {
+		fmt.Fprintf(os.Stderr, "Moralis API: %d call(s), %d rate limited, %d failed\n", usage.Calls, usage.RateLimited, usage.Failed)
+	}
+}
+
+// scan queries every configured account, prices the balances and applies
+// filter. incomplete is set when a query or the price refresh failed.
+func scan(cfg config.Config, filter portfolio.Filter) (balances []portfolio.Balance, incomplete error, err error) {
+	priceProviders, err := price.NewProviders(cfg.PriceProviders, cfg.CoinGeckoURI)
+	if err != nil {
+		return nil, nil, fmt.Errorf("configuring price providers: %v", err)
+	}
+	loadAssetOverrides(cfg)
+
+	balances, _, incomplete = newRefresher(cfg, filter, priceProviders, 0).refresh()
+	return balances, incomplete, nil
+}
+
+func loadAssetOverrides(cfg config.Config) {
+	// Initialize IBC data
+	if cfg.IBCAssetsFile != "" {
+		overrides, err := config.LoadIBCAssets(cfg.IBCAssetsFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading asset overrides: %v\n", err)
+		} else {
+			cosmos.SetAssetOverrides(overrides)
+		}
+	}
+
+	if len(cfg.TokenLists) > 0 {
+		tokens, err := config.LoadTokenLists(cfg.TokenLists)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading token lists: %v\n", err)
+		} else {
+			evm.SetTokenList(tokens)
+		}
+	}
+}
+
+// collect queries the balances of every configured account on the networks
+// that pass filter, labelled from the address entries but not yet priced.
+func collect(cfg config.Config, filter portfolio.Filter) []portfolio.Balance {
+	// Create channels for collecting balances
+	balanceChan := make(chan portfolio.Balance, 1000)
+	var wg sync.WaitGroup
+
+	// Configured entries by the account they are queried as, for labelling
+	accounts := make(map[string]config.Address)
+
+	evm.ResetFilteredTokens()
+
+	// Add fixed balances
+	portfolio.AddFixedBalances(cfg.FixedBalances, balanceChan)
+
+	// Query Cosmos networks
+	for _, networkName := range cfg.CosmosNetworks {
+		if !filter.MatchesNetwork(networkName) {
+			continue
+		}
+
+		chainInfo, err := cosmos.FetchChainInfo(networkName)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error fetching chain info for %s: %v\n", networkName, err)
+			continue
+		}
+
+		for _, address := range cfg.CosmosAddresses {
+			networkAddress, err := utils.ConvertCosmosAddress(address.Address, chainInfo.Bech32Prefix)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error converting address for %s: %v\n", networkName, err)
+				continue
+			}
+			accounts[strings.ToLower(networkAddress)] = address
+
+			wg.Add(1)
+			go func(network, addr string) {
+				defer wg.Done()
+				cosmos.QueryBalances(network, addr, balanceChan)
+			}(networkName, networkAddress)
+		}
+
+		for _, address := range cfg.ValidatorAddresses {
+			valoper, err := utils.ConvertCosmosAddress(address.Address, chainInfo.Bech32Prefix+"valoper")
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error converting validator address for %s: %v\n", networkName, err)
+				continue
+			}
+			accounts[strings.ToLower(valoper)] = address
+
+			wg.Add(1)
+			go func(network, addr string) {
+				defer wg.Done()
+				cosmos.QueryCommission(network, addr, balanceChan)
+			}(networkName, valoper)
+		}
+	}
+
+	// Query EVM networks
+	for _, network := range cfg.EVMNetworks {
+		if !filter.MatchesNetwork(network.Name) {
+			continue
+		}
+
+		for _, address := range cfg.EVMAddresses {
+			accounts[strings.ToLower(address.Address)] = address
+
+			wg.Add(1)
+			go func(net config.EVMNetwork, addr string) {
+				defer wg.Done()
+				evm.QueryBalances(net, addr, balanceChan)
+			}(network, address.Address)
+		}
+	}
+
+	// Close channel after all goroutines complete
+	go func() {
+		wg.Wait()
+		close(balanceChan)
+	}()
+
+	balances := portfolio.CollectBalances(balanceChan)
+	portfolio.LabelBalances(balances, accounts)
+	return balances
+}
+
+// value prices balances with the loaded prices and applies filter.
+func value(balances []portfolio.Balance, filter portfolio.Filter) []portfolio.Balance {
+	return portfolio.FilterBalances(portfolio.PriceBalances(balances), filter)
+}
+
+func saveSnapshot(cfg config.Config, snapshot portfolio.Snapshot) {
+	db, err := store.Open(snapshotPath(cfg))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening snapshot store: %v\n", err)
+		return
+	}
+	defer db.Close()
+
+	if err := db.Save(snapshot); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving snapshot: %v\n", err)
+	}
+}
+
+func snapshotPath(cfg config.Config) string {
+	if cfg.SnapshotDB != "" {
+		return cfg.SnapshotDB
+	}
+	return store.DefaultPath()
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/anilcse/cosmoscope/internal/portfolio"
+	"github.com/anilcse/cosmoscope/internal/store"
+)
+
+func runHistory(args []string) {
+	flags := flag.NewFlagSet("history", flag.ExitOnError)
+	opts := addGlobalFlags(flags)
+	limit := flags.Int("limit", 30, "number of most recent snapshots to show (0 for all)")
+	_ = flags.Parse(args)
+
+	cfg := opts.load()
+	db, err := store.Open(snapshotPath(cfg))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening snapshot store: %v\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	snapshots, err := db.List(*limit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading snapshots: %v\n", err)
+		return
+	}
+
+	portfolio.PrintHistory(snapshots)
+}
+
+func runDiff(args []string) {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	opts := addGlobalFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: cosmoscope diff [flags] <snapshot> <snapshot>")
+		fmt.Fprintln(os.Stderr, "Snapshots are named by ID (see cosmoscope history), latest or previous.")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+	if flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	cfg := opts.load()
+	db, err := store.Open(snapshotPath(cfg))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening snapshot store: %v\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	before, err := db.Get(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading snapshot: %v\n", err)
+		return
+	}
+	after, err := db.Get(flags.Arg(1))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading snapshot: %v\n", err)
+		return
+	}
+
+	portfolio.PrintDiff(before, after)
+}
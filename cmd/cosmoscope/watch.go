@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/anilcse/cosmoscope/internal/evm"
+	"github.com/anilcse/cosmoscope/internal/portfolio"
+	"github.com/anilcse/cosmoscope/internal/price"
+	"github.com/fatih/color"
+)
+
+// clearScreen moves the cursor home and clears the terminal so each refresh
+// redraws the report in place.
+const clearScreen = "\033[H\033[2J"
+
+// runWatch refreshes the report on an interval until interrupted. Chain
+// info, asset lists and the selected REST endpoints are cached by the
+// cosmos package, so only balances are queried again on every cycle.
+func runWatch(args []string) {
+	flags := flag.NewFlagSet("watch", flag.ExitOnError)
+	opts := addGlobalFlags(flags)
+	filters := addFilterFlags(flags)
+	output := flags.String("output", portfolio.OutputTable, "report format: table, json, csv or ndjson")
+	interval := flags.Duration("interval", 5*time.Minute, "time between balance refreshes")
+	priceInterval := flags.Duration("price-interval", 15*time.Minute, "time between price refreshes")
+	noClear := flags.Bool("no-clear", false, "append each table report instead of redrawing in place")
+	snapshot := flags.Bool("snapshot", false, "store a snapshot after every refresh")
+	_ = flags.Parse(args)
+
+	if !portfolio.ValidOutput(*output) {
+		fmt.Fprintf(os.Stderr, "Unknown output format %q\n", *output)
+		os.Exit(2)
+	}
+	if *interval <= 0 || *priceInterval <= 0 {
+		fmt.Fprintln(os.Stderr, "-interval and -price-interval must be positive")
+		os.Exit(2)
+	}
+
+	filter := filters.filter()
+	cfg := opts.load()
+	if *output != portfolio.OutputTable {
+		color.NoColor = true
+	}
+
+	priceProviders, err := price.NewProviders(cfg.PriceProviders, cfg.CoinGeckoURI)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error configuring price providers: %v\n", err)
+		os.Exit(1)
+	}
+	loadAssetOverrides(cfg)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+
+	// Alerts compare every refresh with the last stored snapshot, or the
+	// first complete refresh when there is none. Without -snapshot that
+	// baseline stays fixed, so a slow decline still crosses a value_drop
+	// threshold. Alerts are only delivered when they start firing.
+	var baseline *portfolio.Snapshot
+	if filter.IsZero() {
+		baseline = latestSnapshot(cfg)
+	}
+	activeAlerts := make(map[string]bool)
+
+	refresher := newRefresher(cfg, filter, priceProviders, *priceInterval)
+	for {
+		balances, _, incomplete := refresher.refresh()
+
+		if *output == portfolio.OutputTable {
+			if !*noClear {
+				fmt.Print(clearScreen)
+			}
+			portfolio.PrintHeader()
+			portfolio.PrintBalanceReport(balances, evm.FilteredTokens())
+			prices := "Prices not loaded yet"
+			if !refresher.lastPriced.IsZero() {
+				prices = "Prices from " + refresher.lastPriced.Format("15:04:05")
+			}
+			fmt.Printf("%s. Next refresh at %s (Ctrl+C to stop).\n", prices, time.Now().Add(*interval).Format("15:04:05"))
+		} else if err := portfolio.WriteReport(os.Stdout, *output, balances, evm.FilteredTokens()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing report: %v\n", err)
+		}
+		printMoralisUsage()
+
+		// An incomplete refresh is neither checked nor stored, so it never
+		// becomes the baseline of later alerts or a point in the history.
+		if filter.IsZero() {
+			current := portfolio.NewSnapshot(balances)
+			if incomplete != nil {
+				fmt.Fprintf(os.Stderr, "Skipping alerts and snapshot because %v\n", incomplete)
+			} else {
+				checkAlerts(cfg, baseline, balances, activeAlerts)
+				if baseline == nil {
+					baseline = &current
+				}
+				if *snapshot {
+					saveSnapshot(cfg, current)
+					baseline = &current
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
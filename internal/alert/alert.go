@@ -0,0 +1,163 @@
+// Package alert evaluates the configured alert rules against a scan and
+// delivers the alerts that fire.
+package alert
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/anilcse/cosmoscope/internal/config"
+	"github.com/anilcse/cosmoscope/internal/portfolio"
+	"github.com/anilcse/cosmoscope/pkg/utils"
+)
+
+// Alert is a rule that fired.
+type Alert struct {
+	Rule    string    `json:"rule"`
+	Type    string    `json:"type"`
+	Subject string    `json:"subject,omitempty"`
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+}
+
+// Key identifies what an alert is about, so a condition that holds across
+// several refreshes can be reported once.
+func (a Alert) Key() string {
+	return a.Rule + "/" + a.Subject
+}
+
+// Evaluate checks rules against balances. previous is the last stored
+// snapshot, or nil when there is none; rules that compare against it are
+// skipped without one.
+func Evaluate(rules []config.AlertRule, previous *portfolio.Snapshot, balances []portfolio.Balance) []Alert {
+	now := time.Now().UTC()
+	var alerts []Alert
+	for _, rule := range rules {
+		matching := onNetwork(balances, rule.Network)
+		name := ruleName(rule)
+
+		switch rule.Type {
+		case config.AlertValueDrop:
+			if previous == nil {
+				continue
+			}
+			before := total(onNetwork(previous.Balances, rule.Network))
+			after := total(matching)
+			if before.Sign() <= 0 {
+				continue
+			}
+			drop := before.Sub(after).Float64() / before.Float64() * 100
+			if drop >= rule.Percent {
+				alerts = append(alerts, Alert{Rule: name, Type: rule.Type, Time: now,
+					Message: fmt.Sprintf("portfolio value fell %.2f%% from $%.2f to $%.2f",
+						drop, before.Float64(), after.Float64())})
+			}
+
+		case config.AlertRewardsAbove:
+			var rewards utils.Amount
+			for _, b := range matching {
+				if !isReward(b) {
+					continue
+				}
+				if rule.Token == "" {
+					rewards = rewards.Add(b.USDValue)
+				} else if strings.EqualFold(b.Token, rule.Token) {
+					rewards = rewards.Add(b.Amount)
+				}
+			}
+			if rewards.Cmp(rule.Threshold) > 0 {
+				message := fmt.Sprintf("unclaimed rewards are worth $%.2f", rewards.Float64())
+				if rule.Token != "" {
+					message = fmt.Sprintf("unclaimed %s rewards are %s", strings.ToUpper(rule.Token), rewards)
+				}
+				alerts = append(alerts, Alert{Rule: name, Type: rule.Type, Subject: rule.Token, Time: now, Message: message})
+			}
+
+		case config.AlertBalanceBelow:
+			var amount utils.Amount
+			for _, b := range matching {
+				if strings.EqualFold(b.Token, rule.Token) {
+					amount = amount.Add(b.Amount)
+				}
+			}
+			if amount.Cmp(rule.Threshold) < 0 {
+				alerts = append(alerts, Alert{Rule: name, Type: rule.Type, Subject: rule.Token, Time: now,
+					Message: fmt.Sprintf("%s balance %s is below %s", strings.ToUpper(rule.Token), amount, rule.Threshold)})
+			}
+
+		case config.AlertNewToken:
+			if previous == nil {
+				continue
+			}
+			held := make(map[string]bool)
+			for _, b := range onNetwork(previous.Balances, rule.Network) {
+				held[b.Token] = true
+			}
+			for _, token := range newTokens(matching, held) {
+				alerts = append(alerts, Alert{Rule: name, Type: rule.Type, Subject: token.Token, Time: now,
+					Message: fmt.Sprintf("new token %s appeared in %s on %s", token.Token, token.AccountName(), token.Network)})
+			}
+		}
+	}
+	return alerts
+}
+
+func ruleName(rule config.AlertRule) string {
+	if rule.Name != "" {
+		return rule.Name
+	}
+	return rule.Type
+}
+
+// onNetwork returns the balances on network, matched like the --network
+// filter: the full network name or the chain name before its "-" suffix.
+func onNetwork(balances []portfolio.Balance, network string) []portfolio.Balance {
+	if network == "" {
+		return balances
+	}
+	var matching []portfolio.Balance
+	for _, b := range balances {
+		if portfolio.MatchesNetwork(b, network) {
+			matching = append(matching, b)
+		}
+	}
+	return matching
+}
+
+func total(balances []portfolio.Balance) utils.Amount {
+	var sum utils.Amount
+	for _, b := range balances {
+		sum = sum.Add(b.USDValue)
+	}
+	return sum
+}
+
+// isReward reports whether b is claimable but not yet claimed: delegation
+// rewards or validator commission.
+func isReward(b portfolio.Balance) bool {
+	switch portfolio.AssetType(b) {
+	case "Rewards", "Commission":
+		return true
+	}
+	return false
+}
+
+// newTokens returns the first balance of every token not in held, ordered
+// by token.
+func newTokens(balances []portfolio.Balance, held map[string]bool) []portfolio.Balance {
+	seen := make(map[string]bool)
+	var found []portfolio.Balance
+	for _, b := range balances {
+		if held[b.Token] || seen[b.Token] {
+			continue
+		}
+		seen[b.Token] = true
+		found = append(found, b)
+	}
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].Token < found[j].Token
+	})
+	return found
+}
@@ -0,0 +1,149 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"testing"
+
+	"github.com/anilcse/cosmoscope/internal/config"
+	"github.com/anilcse/cosmoscope/internal/portfolio"
+	"github.com/anilcse/cosmoscope/pkg/utils"
+)
+
+func amount(s string) utils.Amount {
+	return utils.ParseAmount(s, 0)
+}
+
+func TestEvaluate(t *testing.T) {
+	previous := &portfolio.Snapshot{Balances: []portfolio.Balance{
+		{Network: "cosmoshub-bank", Token: "ATOM", Amount: amount("100"), USDValue: amount("1000")},
+	}}
+	balances := []portfolio.Balance{
+		{Network: "cosmoshub-bank", Token: "ATOM", Amount: amount("80"), USDValue: amount("800")},
+		{Network: "cosmoshub-rewards", Token: "ATOM", Amount: amount("3"), USDValue: amount("30")},
+		{Network: "osmosis-bank", Account: "osmo1a", Token: "OSMO", Amount: amount("5"), USDValue: amount("2")},
+	}
+
+	tests := []struct {
+		name string
+		rule config.AlertRule
+		want string
+	}{
+		{"value drop", config.AlertRule{Type: config.AlertValueDrop, Percent: 10}, "portfolio value fell 16.80% from $1000.00 to $832.00"},
+		{"small drop", config.AlertRule{Type: config.AlertValueDrop, Percent: 20}, ""},
+		{"rewards usd", config.AlertRule{Type: config.AlertRewardsAbove, Threshold: amount("25")}, "unclaimed rewards are worth $30.00"},
+		{"rewards tokens", config.AlertRule{Type: config.AlertRewardsAbove, Token: "atom", Threshold: amount("5")}, ""},
+		{"balance below", config.AlertRule{Type: config.AlertBalanceBelow, Token: "ATOM", Threshold: amount("90")}, "ATOM balance 83 is below 90"},
+		{"balance on network", config.AlertRule{Type: config.AlertBalanceBelow, Token: "ATOM", Network: "osmosis", Threshold: amount("1")}, "ATOM balance 0 is below 1"},
+		{"balance on category", config.AlertRule{Type: config.AlertBalanceBelow, Token: "ATOM", Network: "cosmoshub-rewards", Threshold: amount("4")}, "ATOM balance 3 is below 4"},
+		{"network prefix", config.AlertRule{Type: config.AlertRewardsAbove, Network: "cosmos", Threshold: amount("1")}, ""},
+		{"new token", config.AlertRule{Type: config.AlertNewToken}, "new token OSMO appeared in osmo1a on osmosis-bank"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alerts := Evaluate([]config.AlertRule{tt.rule}, previous, balances)
+			if tt.want == "" {
+				if len(alerts) != 0 {
+					t.Fatalf("got %v, want no alerts", alerts)
+				}
+				return
+			}
+			if len(alerts) != 1 || alerts[0].Message != tt.want {
+				t.Fatalf("got %v, want %q", alerts, tt.want)
+			}
+		})
+	}
+
+	if alerts := Evaluate([]config.AlertRule{{Type: config.AlertNewToken}}, nil, balances); len(alerts) != 0 {
+		t.Errorf("new_token without a snapshot fired: %v", alerts)
+	}
+}
+
+func TestSinks(t *testing.T) {
+	var received []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		received = append(received, body)
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	sinks, err := NewSinks([]config.AlertSink{
+		{Type: config.SinkWebhook, URL: server.URL + "/hook"},
+		{Type: config.SinkSlack, URL: server.URL + "/slack"},
+		{Type: config.SinkWebhook, URL: server.URL + "/fail"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	alerts := []Alert{{Rule: "drop", Type: config.AlertValueDrop, Message: "portfolio value fell 20.00%"}}
+	err = Notify(context.Background(), sinks, alerts)
+	if err == nil || !strings.Contains(err.Error(), "status 500") {
+		t.Errorf("Notify error = %v, want the failing sink's status", err)
+	}
+	if len(received) != 3 {
+		t.Fatalf("got %d requests, want 3", len(received))
+	}
+	if got := received[0]["alerts"].([]interface{})[0].(map[string]interface{})["message"]; got != alerts[0].Message {
+		t.Errorf("webhook message = %v", got)
+	}
+	if got := received[1]["text"]; got != "[drop] portfolio value fell 20.00%" {
+		t.Errorf("slack text = %v", got)
+	}
+}
+
+func TestEmailSink(t *testing.T) {
+	sinks, err := NewSinks([]config.AlertSink{{Type: config.SinkEmail, SMTPHost: "smtp.example.com", From: "a@example.com", To: []string{"b@example.com"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink := sinks[0].(*emailSink)
+
+	var addr, msg string
+	sink.sendMail = func(a string, _ smtp.Auth, _ string, _ []string, m []byte) error {
+		addr, msg = a, string(m)
+		return nil
+	}
+	if err := sink.Send(context.Background(), []Alert{{Rule: "floor", Message: "ATOM balance 1 is below 5"}}); err != nil {
+		t.Fatal(err)
+	}
+	if addr != "smtp.example.com:587" {
+		t.Errorf("addr = %q", addr)
+	}
+	if !strings.Contains(msg, "Subject: CosmoScope: ATOM balance 1 is below 5\r\n") || !strings.HasSuffix(msg, "[floor] ATOM balance 1 is below 5") {
+		t.Errorf("unexpected message:\n%s", msg)
+	}
+}
+
+func TestEmailSinkSubject(t *testing.T) {
+	var msg string
+	sink := &emailSink{from: "a@example.com", to: []string{"b@example.com"}, sendMail: func(_ string, _ smtp.Auth, _ string, _ []string, m []byte) error {
+		msg = string(m)
+		return nil
+	}}
+
+	if err := sink.Send(context.Background(), []Alert{{Rule: "new", Message: "new token X\r\nBcc: evil@example.com"}}); err != nil {
+		t.Fatal(err)
+	}
+	headers := msg[:strings.Index(msg, "\r\n\r\n")]
+	if strings.Contains(headers, "\r\nBcc:") {
+		t.Errorf("subject injected a header:\n%s", headers)
+	}
+
+	if err := sink.Send(context.Background(), []Alert{{Rule: "new", Message: "new token ☃"}}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(msg, "Subject: =?utf-8?q?CosmoScope:_new_token_=E2=98=83?=\r\n") {
+		t.Errorf("non-ASCII subject not encoded:\n%s", msg)
+	}
+}
@@ -0,0 +1,162 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anilcse/cosmoscope/internal/config"
+)
+
+// Sink delivers alerts.
+type Sink interface {
+	Send(ctx context.Context, alerts []Alert) error
+}
+
+// NewSinks builds the configured sinks.
+func NewSinks(cfgs []config.AlertSink) ([]Sink, error) {
+	var sinks []Sink
+	for i, cfg := range cfgs {
+		switch cfg.Type {
+		case config.SinkWebhook:
+			sinks = append(sinks, &webhookSink{url: cfg.URL, client: newHTTPClient()})
+		case config.SinkSlack:
+			sinks = append(sinks, &slackSink{url: cfg.URL, client: newHTTPClient()})
+		case config.SinkEmail:
+			port := cfg.SMTPPort
+			if port == 0 {
+				port = 587
+			}
+			sink := &emailSink{
+				addr:     net.JoinHostPort(cfg.SMTPHost, strconv.Itoa(port)),
+				from:     cfg.From,
+				to:       cfg.To,
+				sendMail: smtp.SendMail,
+			}
+			if cfg.Username != "" {
+				sink.auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.SMTPHost)
+			}
+			sinks = append(sinks, sink)
+		default:
+			return nil, fmt.Errorf("alert sink %d: unknown type %q", i, cfg.Type)
+		}
+	}
+	return sinks, nil
+}
+
+// Notify sends alerts to every sink. A failing sink does not stop delivery
+// to the others; their errors are returned together.
+func Notify(ctx context.Context, sinks []Sink, alerts []Alert) error {
+	if len(alerts) == 0 {
+		return nil
+	}
+	var errs []string
+	for _, sink := range sinks {
+		if err := sink.Send(ctx, alerts); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("delivering alerts: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func newHTTPClient() *http.Client {
+	return &http.Client{Timeout: time.Second * 10}
+}
+
+// webhookSink posts the alerts as JSON: {"alerts": [...]}.
+type webhookSink struct {
+	url    string
+	client *http.Client
+}
+
+func (s *webhookSink) Send(ctx context.Context, alerts []Alert) error {
+	return postJSON(ctx, s.client, s.url, struct {
+		Alerts []Alert `json:"alerts"`
+	}{alerts})
+}
+
+// slackSink posts a Slack incoming-webhook message, which Mattermost,
+// Discord (/slack) and similar services also accept.
+type slackSink struct {
+	url    string
+	client *http.Client
+}
+
+func (s *slackSink) Send(ctx context.Context, alerts []Alert) error {
+	return postJSON(ctx, s.client, s.url, struct {
+		Text string `json:"text"`
+	}{summary(alerts)})
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned status %d", url, resp.StatusCode)
+	}
+	return nil
+}
+
+type emailSink struct {
+	addr     string
+	auth     smtp.Auth
+	from     string
+	to       []string
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+func (s *emailSink) Send(_ context.Context, alerts []Alert) error {
+	subject := fmt.Sprintf("CosmoScope: %d alert(s)", len(alerts))
+	if len(alerts) == 1 {
+		subject = "CosmoScope: " + alerts[0].Message
+	}
+	// Messages carry token symbols from chain data, so a line break must
+	// not start a new header and other text is encoded for the header.
+	subject = strings.NewReplacer("\r", " ", "\n", " ").Replace(subject)
+	subject = mime.QEncoding.Encode("utf-8", subject)
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", s.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(s.to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&msg, "Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(summary(alerts), "\n", "\r\n"))
+
+	if err := s.sendMail(s.addr, s.auth, s.from, s.to, msg.Bytes()); err != nil {
+		return fmt.Errorf("sending mail via %s: %v", s.addr, err)
+	}
+	return nil
+}
+
+// summary renders alerts as one line each.
+func summary(alerts []Alert) string {
+	var lines []string
+	for _, a := range alerts {
+		lines = append(lines, fmt.Sprintf("[%s] %s", a.Rule, a.Message))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -4,23 +4,65 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 )
 
+// DefaultPath is the config file used when none is given on the command line.
+const DefaultPath = "configs/config.json"
+
 var GlobalConfig Config
 
-func Load() Config {
-	file, err := os.ReadFile("configs/config.json")
+// Load reads the JSON, YAML or TOML config file at path and makes it the
+// GlobalConfig.
+func Load(path string) (Config, error) {
+	file, err := os.ReadFile(path)
 	if err != nil {
-		panic(fmt.Sprintf("Error reading config file: %v", err))
+		return Config{}, fmt.Errorf("error reading config file: %v", err)
 	}
 
-	if err := json.Unmarshal(file, &GlobalConfig); err != nil {
-		panic(fmt.Sprintf("Error parsing config file: %v", err))
+	cfg, err := Parse(path, file)
+	if err != nil {
+		return Config{}, fmt.Errorf("error parsing config file %s: %v", path, err)
 	}
+	resolvePaths(&cfg, filepath.Dir(path))
 
-	return GlobalConfig
+	GlobalConfig = cfg
+	return cfg, nil
 }
 
+// AssetKey is the key of an asset override for denom on network. Overrides
+// without a network use an empty network and apply to every chain.
+func AssetKey(network, denom string) string {
+	if network == "" {
+		return denom
+	}
+	return network + ":" + denom
+}
+
+// resolvePaths makes the relative file paths in cfg relative to dir, the
+// directory of the config file, so a config works from any working
+// directory.
+func resolvePaths(cfg *Config, dir string) {
+	resolve := func(path string) string {
+		if path == "" || filepath.IsAbs(path) {
+			return path
+		}
+		return filepath.Join(dir, path)
+	}
+
+	cfg.IBCAssetsFile = resolve(cfg.IBCAssetsFile)
+	cfg.SnapshotDB = resolve(cfg.SnapshotDB)
+	for i, path := range cfg.TokenLists {
+		cfg.TokenLists[i] = resolve(path)
+	}
+	for i, provider := range cfg.PriceProviders {
+		cfg.PriceProviders[i].File = resolve(provider.File)
+	}
+}
+
+// LoadIBCAssets reads the asset overrides file and indexes the entries by
+// AssetKey, so the same denom can carry different metadata per network.
 func LoadIBCAssets(filepath string) (map[string]*IBCAsset, error) {
 	file, err := os.ReadFile(filepath)
 	if err != nil {
@@ -32,16 +74,56 @@ func LoadIBCAssets(filepath string) (map[string]*IBCAsset, error) {
 		return nil, fmt.Errorf("error parsing IBC assets file: %v", err)
 	}
 
+	// Every asset type (native, ibc, cw20, factory) can be overridden.
 	assetMap := make(map[string]*IBCAsset)
 	for _, asset := range assets {
-		if asset.Type == "ibc" {
-			assetCopy := asset
-			assetMap[asset.Denom] = &assetCopy
-			if _, exists := assetMap[asset.Symbol]; !exists {
-				assetMap[asset.Symbol] = &assetCopy
-			}
+		if asset.Denom == "" {
+			continue
 		}
+		assetCopy := asset
+		assetMap[AssetKey(asset.Network, asset.Denom)] = &assetCopy
 	}
 
 	return assetMap, nil
 }
+
+// LoadTokenLists reads token list files and indexes their tokens by chain
+// ID and lowercase contract address. When lists disagree, the first list
+// to name a contract wins.
+func LoadTokenLists(paths []string) (map[int]map[string]EVMToken, error) {
+	tokens := make(map[int]map[string]EVMToken)
+	for _, path := range paths {
+		file, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading token list: %v", err)
+		}
+
+		var list TokenList
+		if err := json.Unmarshal(file, &list); err != nil {
+			return nil, fmt.Errorf("error parsing token list %s: %v", path, err)
+		}
+
+		for _, entry := range list.Tokens {
+			if entry.Address == "" || entry.Symbol == "" {
+				continue
+			}
+			byAddress, exists := tokens[entry.ChainID]
+			if !exists {
+				byAddress = make(map[string]EVMToken)
+				tokens[entry.ChainID] = byAddress
+			}
+			address := strings.ToLower(entry.Address)
+			if _, exists := byAddress[address]; exists {
+				continue
+			}
+			decimals := entry.Decimals
+			byAddress[address] = EVMToken{
+				Contract:    entry.Address,
+				Symbol:      entry.Symbol,
+				Decimals:    &decimals,
+				CoinGeckoID: entry.CoinGeckoID(),
+			}
+		}
+	}
+	return tokens, nil
+}
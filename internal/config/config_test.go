@@ -0,0 +1,71 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadIBCAssets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "assets.json")
+	err := os.WriteFile(path, []byte(`[
+		{"denom": "uusdc", "symbol": "USDC", "decimals": 6},
+		{"denom": "uusdc", "symbol": "USDC.n", "decimals": 6, "network": "noble"},
+		{"symbol": "SKIPPED"}
+	]`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assets, err := LoadIBCAssets(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(assets) != 2 {
+		t.Fatalf("got %d overrides, want 2: %v", len(assets), assets)
+	}
+	if got := assets[AssetKey("", "uusdc")]; got == nil || got.Symbol != "USDC" {
+		t.Errorf("network-less override = %+v", got)
+	}
+	if got := assets[AssetKey("noble", "uusdc")]; got == nil || got.Symbol != "USDC.n" {
+		t.Errorf("noble override = %+v", got)
+	}
+}
+
+func TestLoadResolvesPathsAgainstConfigDir(t *testing.T) {
+	dir := t.TempDir()
+	absolute := filepath.Join(t.TempDir(), "list.json")
+	path := filepath.Join(dir, "config.json")
+	err := os.WriteFile(path, []byte(`{
+		"ibc_assets_file": "assets.json",
+		"snapshot_db": "data/snapshots.db",
+		"token_lists": ["lists/default.json", "`+filepath.ToSlash(absolute)+`"],
+		"price_providers": [{"type": "static", "file": "prices.json"}, {"type": "coingecko"}]
+	}`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "assets.json"); cfg.IBCAssetsFile != want {
+		t.Errorf("ibc_assets_file = %q, want %q", cfg.IBCAssetsFile, want)
+	}
+	if want := filepath.Join(dir, "data", "snapshots.db"); cfg.SnapshotDB != want {
+		t.Errorf("snapshot_db = %q, want %q", cfg.SnapshotDB, want)
+	}
+	if want := filepath.Join(dir, "lists", "default.json"); cfg.TokenLists[0] != want {
+		t.Errorf("token_lists[0] = %q, want %q", cfg.TokenLists[0], want)
+	}
+	if cfg.TokenLists[1] != absolute {
+		t.Errorf("absolute token list changed to %q", cfg.TokenLists[1])
+	}
+	if want := filepath.Join(dir, "prices.json"); cfg.PriceProviders[0].File != want {
+		t.Errorf("static price file = %q, want %q", cfg.PriceProviders[0].File, want)
+	}
+	if cfg.PriceProviders[1].File != "" {
+		t.Errorf("coingecko file = %q, want empty", cfg.PriceProviders[1].File)
+	}
+}
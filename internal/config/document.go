@@ -0,0 +1,409 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// envPrefix prefixes the environment variables that override top-level
+// config fields, e.g. COSMOSCOPE_MORALIS_API_KEY.
+const envPrefix = "COSMOSCOPE_"
+
+var envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// Parse decodes a JSON, YAML or TOML config, chosen by the extension of
+// name, then expands ${VAR} references and applies COSMOSCOPE_* overrides.
+func Parse(name string, data []byte) (Config, error) {
+	doc, err := loadDocument(name, data)
+	if err != nil {
+		return Config{}, err
+	}
+
+	var cfg Config
+	if err := fromDocument(doc, &cfg); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// loadDocument returns the config as generic JSON-compatible values with
+// environment references and overrides applied.
+func loadDocument(name string, data []byte) (map[string]interface{}, error) {
+	var raw interface{}
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml":
+		var node yaml.Node
+		if err := yaml.Unmarshal(data, &node); err != nil {
+			return nil, fmt.Errorf("invalid YAML: %v", err)
+		}
+		value, err := yamlValue(&node)
+		if err != nil {
+			return nil, fmt.Errorf("invalid YAML: %v", err)
+		}
+		raw = value
+	case ".toml":
+		var table map[string]interface{}
+		if err := toml.Unmarshal(markTOMLFloats(data), &table); err != nil {
+			return nil, fmt.Errorf("invalid TOML: %v", err)
+		}
+		raw = restoreTOMLFloats(table)
+	default:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err := decoder.Decode(&raw); err != nil {
+			return nil, err
+		}
+	}
+
+	doc, ok := normalizeDocument(raw).(map[string]interface{})
+	if !ok {
+		if raw == nil {
+			return map[string]interface{}{}, nil
+		}
+		return nil, fmt.Errorf("config must be an object")
+	}
+
+	expanded, err := expandEnv(doc)
+	if err != nil {
+		return nil, err
+	}
+	doc = expanded.(map[string]interface{})
+
+	if err := applyEnvOverrides(doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// fromDocument decodes generic values into v using the JSON field tags, so
+// every format shares the same field names.
+func fromDocument(doc map[string]interface{}, v interface{}) error {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// normalizeDocument converts the map and slice types produced by the YAML
+// and TOML decoders into the ones encoding/json produces.
+func normalizeDocument(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for key, child := range value {
+			value[key] = normalizeDocument(child)
+		}
+		return value
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(value))
+		for key, child := range value {
+			converted[fmt.Sprint(key)] = normalizeDocument(child)
+		}
+		return converted
+	case []map[string]interface{}:
+		converted := make([]interface{}, len(value))
+		for i, child := range value {
+			converted[i] = normalizeDocument(child)
+		}
+		return converted
+	case []interface{}:
+		for i, child := range value {
+			value[i] = normalizeDocument(child)
+		}
+		return value
+	default:
+		return v
+	}
+}
+
+// yamlValue converts node into the values yaml.Unmarshal produces, except
+// that numbers become json.Numbers so amounts keep every digit instead of
+// being rounded to a float64.
+func yamlValue(node *yaml.Node) (interface{}, error) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		return yamlValue(node.Content[0])
+	case yaml.AliasNode:
+		return yamlValue(node.Alias)
+	case yaml.SequenceNode:
+		list := make([]interface{}, 0, len(node.Content))
+		for _, child := range node.Content {
+			value, err := yamlValue(child)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		return list, nil
+	case yaml.MappingNode:
+		mapping := make(map[string]interface{})
+		var merged []interface{}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			value, err := yamlValue(node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			if node.Content[i].ShortTag() == "!!merge" {
+				if list, ok := value.([]interface{}); ok {
+					merged = append(merged, list...)
+				} else {
+					merged = append(merged, value)
+				}
+				continue
+			}
+			mapping[node.Content[i].Value] = value
+		}
+		// Keys of the mapping itself win over merged ones.
+		for _, m := range merged {
+			source, ok := m.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("line %d: only mappings can be merged", node.Line)
+			}
+			for key, value := range source {
+				if _, exists := mapping[key]; !exists {
+					mapping[key] = value
+				}
+			}
+		}
+		return mapping, nil
+	case yaml.ScalarNode:
+		if tag := node.ShortTag(); (tag == "!!int" || tag == "!!float") && json.Valid([]byte(node.Value)) {
+			return json.Number(node.Value), nil
+		}
+		var value interface{}
+		if err := node.Decode(&value); err != nil {
+			return nil, err
+		}
+		return value, nil
+	}
+	return nil, nil
+}
+
+// tomlFloatMark starts the strings markTOMLFloats puts in place of float
+// literals. TOML strings can only contain NUL as an escape, so a config
+// value cannot start with it.
+const tomlFloatMark = "\x00"
+
+var tomlFloat = regexp.MustCompile(`^[+-]?\d[\d_]*(\.\d[\d_]*)?([eE][+-]?\d[\d_]*)?$`)
+
+// markTOMLFloats rewrites every float literal in a TOML document as a
+// marked string, since the TOML decoder rounds floats to float64.
+// restoreTOMLFloats turns them back into json.Numbers with every digit.
+func markTOMLFloats(data []byte) []byte {
+	var out bytes.Buffer
+	var stack []byte // '[' for arrays and '{' for inline tables
+	expectValue := false
+
+	copyUntil := func(i int, end string, escapes bool) int {
+		for j := i; j < len(data); j++ {
+			if escapes && data[j] == '\\' {
+				j++
+				continue
+			}
+			if bytes.HasPrefix(data[j:], []byte(end)) {
+				out.Write(data[i : j+len(end)])
+				return j + len(end)
+			}
+		}
+		out.Write(data[i:])
+		return len(data)
+	}
+
+	for i := 0; i < len(data); {
+		c := data[i]
+		switch {
+		case c == '#':
+			i = copyUntil(i, "\n", false)
+			if len(stack) == 0 {
+				expectValue = false
+			}
+		case c == '"' || c == '\'':
+			quote := string(c)
+			if bytes.HasPrefix(data[i:], []byte(quote+quote+quote)) {
+				out.WriteString(quote + quote + quote)
+				i = copyUntil(i+3, quote+quote+quote, c == '"')
+			} else {
+				out.WriteByte(c)
+				i = copyUntil(i+1, quote, c == '"')
+			}
+			expectValue = false
+		case c == '=':
+			out.WriteByte(c)
+			i++
+			expectValue = true
+		case c == '[' && !expectValue && len(stack) == 0:
+			// A table header.
+			i = copyUntil(i, "\n", false)
+		case c == '[' || c == '{':
+			out.WriteByte(c)
+			i++
+			stack = append(stack, c)
+			expectValue = c == '['
+		case c == ']' || c == '}':
+			out.WriteByte(c)
+			i++
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			expectValue = false
+		case c == ',':
+			out.WriteByte(c)
+			i++
+			expectValue = len(stack) > 0 && stack[len(stack)-1] == '['
+		case c == '\n':
+			out.WriteByte(c)
+			i++
+			if len(stack) == 0 {
+				expectValue = false
+			}
+		case isTOMLTokenByte(c):
+			j := i
+			for j < len(data) && isTOMLTokenByte(data[j]) {
+				j++
+			}
+			token := string(data[i:j])
+			if expectValue && tomlFloat.MatchString(token) && strings.ContainsAny(token, ".eE") {
+				out.WriteString(`"\u0000` + token + `"`)
+			} else {
+				out.WriteString(token)
+			}
+			i = j
+			if expectValue {
+				expectValue = false
+			}
+		default:
+			out.WriteByte(c)
+			i++
+		}
+	}
+	return out.Bytes()
+}
+
+func isTOMLTokenByte(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || strings.IndexByte("_.:+-", c) >= 0
+}
+
+// restoreTOMLFloats replaces the strings left by markTOMLFloats with the
+// numbers they stand for.
+func restoreTOMLFloats(v interface{}) interface{} {
+	switch value := v.(type) {
+	case string:
+		if !strings.HasPrefix(value, tomlFloatMark) {
+			return value
+		}
+		number := strings.TrimPrefix(strings.ReplaceAll(value[len(tomlFloatMark):], "_", ""), "+")
+		if json.Valid([]byte(number)) {
+			return json.Number(number)
+		}
+		f, _ := strconv.ParseFloat(number, 64)
+		return f
+	case map[string]interface{}:
+		for key, child := range value {
+			value[key] = restoreTOMLFloats(child)
+		}
+		return value
+	case []map[string]interface{}:
+		for _, child := range value {
+			restoreTOMLFloats(child)
+		}
+		return value
+	case []interface{}:
+		for i, child := range value {
+			value[i] = restoreTOMLFloats(child)
+		}
+		return value
+	default:
+		return v
+	}
+}
+
+// expandEnv replaces ${VAR} in every string value. Referencing an unset
+// variable is an error so a missing secret is not silently left empty.
+func expandEnv(v interface{}) (interface{}, error) {
+	switch value := v.(type) {
+	case string:
+		var missing []string
+		expanded := envReference.ReplaceAllStringFunc(value, func(ref string) string {
+			name := envReference.FindStringSubmatch(ref)[1]
+			env, ok := os.LookupEnv(name)
+			if !ok {
+				missing = append(missing, name)
+			}
+			return env
+		})
+		if len(missing) > 0 {
+			return nil, fmt.Errorf("environment variable %s is not set", strings.Join(missing, ", "))
+		}
+		return expanded, nil
+	case map[string]interface{}:
+		for key, child := range value {
+			expanded, err := expandEnv(child)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", key, err)
+			}
+			value[key] = expanded
+		}
+		return value, nil
+	case []interface{}:
+		for i, child := range value {
+			expanded, err := expandEnv(child)
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %v", i, err)
+			}
+			value[i] = expanded
+		}
+		return value, nil
+	default:
+		return v, nil
+	}
+}
+
+// applyEnvOverrides replaces top-level fields with COSMOSCOPE_<FIELD>
+// variables. String fields take the value as is, lists also accept a
+// comma-separated list of strings, and every other field is given as JSON.
+func applyEnvOverrides(doc map[string]interface{}) error {
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		value, ok := os.LookupEnv(envPrefix + strings.ToUpper(name))
+		if !ok {
+			continue
+		}
+
+		switch {
+		case field.Type.Kind() == reflect.String:
+			doc[name] = value
+		case field.Type.Kind() == reflect.Slice && !strings.HasPrefix(strings.TrimSpace(value), "["):
+			var list []interface{}
+			for _, item := range strings.Split(value, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					list = append(list, item)
+				}
+			}
+			doc[name] = list
+		default:
+			var parsed interface{}
+			decoder := json.NewDecoder(strings.NewReader(value))
+			decoder.UseNumber()
+			if err := decoder.Decode(&parsed); err != nil {
+				return fmt.Errorf("%s%s must be JSON: %v", envPrefix, strings.ToUpper(name), err)
+			}
+			doc[name] = parsed
+		}
+	}
+	return nil
+}
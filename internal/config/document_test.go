@@ -0,0 +1,161 @@
+package config
+
+import "testing"
+
+func TestParseFormats(t *testing.T) {
+	t.Setenv("TEST_MORALIS_KEY", "secret")
+
+	files := map[string]string{
+		"config.json": `{
+    "cosmos_networks": ["cosmoshub"],
+    "moralis_api_key": "${TEST_MORALIS_KEY}",
+    "evm_networks": [{"name": "ethereum", "chain_id": 1, "native_token": {"symbol": "ETH", "decimals": 18}}],
+    "fixed_balances": [{"token": "BTC", "amount": 0.12345678901234567890, "label": "Cold"}]
+}`,
+		"config.yaml": `
+cosmos_networks: [cosmoshub]
+moralis_api_key: ${TEST_MORALIS_KEY}
+evm_networks:
+  - name: ethereum
+    chain_id: 1
+    native_token: {symbol: ETH, decimals: 18}
+fixed_balances:
+  - {token: BTC, amount: "0.12345678901234567890", label: Cold}
+`,
+		"config.toml": `
+cosmos_networks = ["cosmoshub"]
+moralis_api_key = "${TEST_MORALIS_KEY}"
+
+[[evm_networks]]
+name = "ethereum"
+chain_id = 1
+native_token = { symbol = "ETH", decimals = 18 }
+
+[[fixed_balances]]
+token = "BTC"
+amount = "0.12345678901234567890"
+label = "Cold"
+`,
+	}
+
+	for name, content := range files {
+		cfg, err := Parse(name, []byte(content))
+		if err != nil {
+			t.Fatalf("Parse(%s) error = %v", name, err)
+		}
+		if cfg.MoralisAPIKey != "secret" {
+			t.Errorf("%s: moralis_api_key = %q, want interpolated secret", name, cfg.MoralisAPIKey)
+		}
+		if len(cfg.EVMNetworks) != 1 || cfg.EVMNetworks[0].ChainID != 1 || cfg.EVMNetworks[0].NativeToken.Decimals != 18 {
+			t.Errorf("%s: evm_networks = %+v", name, cfg.EVMNetworks)
+		}
+		if got := cfg.FixedBalances[0].Amount.String(); got != "0.1234567890123456789" {
+			t.Errorf("%s: fixed balance amount = %v, want exact value", name, got)
+		}
+	}
+}
+
+func TestParseEnvironment(t *testing.T) {
+	if _, err := Parse("config.json", []byte(`{"moralis_api_key": "${TEST_UNSET_VARIABLE}"}`)); err == nil {
+		t.Error("Parse() should fail when a referenced variable is unset")
+	}
+
+	t.Setenv("COSMOSCOPE_MORALIS_API_KEY", "from-env")
+	t.Setenv("COSMOSCOPE_COSMOS_ADDRESSES", "cosmos1a, cosmos1b")
+	t.Setenv("COSMOSCOPE_FIXED_BALANCES", `[{"token": "ETH", "amount": "2", "label": "Exchange"}]`)
+
+	cfg, err := Parse("config.json", []byte(`{"moralis_api_key": "in-file", "cosmos_addresses": ["cosmos1c"]}`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if cfg.MoralisAPIKey != "from-env" {
+		t.Errorf("moralis_api_key = %q, want from-env", cfg.MoralisAPIKey)
+	}
+	if len(cfg.CosmosAddresses) != 2 || cfg.CosmosAddresses[1].Address != "cosmos1b" {
+		t.Errorf("cosmos_addresses = %v", cfg.CosmosAddresses)
+	}
+	if len(cfg.FixedBalances) != 1 || cfg.FixedBalances[0].Token != "ETH" {
+		t.Errorf("fixed_balances = %+v", cfg.FixedBalances)
+	}
+}
+
+func TestParseAddressEntries(t *testing.T) {
+	cfg, err := Parse("config.yaml", []byte(`
+cosmos_addresses:
+  - cosmos1plain
+  - address: cosmos1labelled
+    label: Treasury multisig
+    owner: treasury
+    tags: [treasury, validator-self-bond]
+`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if len(cfg.CosmosAddresses) != 2 {
+		t.Fatalf("len(cosmos_addresses) = %d, want 2", len(cfg.CosmosAddresses))
+	}
+	if got := cfg.CosmosAddresses[0]; got.Address != "cosmos1plain" || got.Label != "" {
+		t.Errorf("plain entry = %+v", got)
+	}
+	got := cfg.CosmosAddresses[1]
+	if got.Address != "cosmos1labelled" || got.Label != "Treasury multisig" || got.Owner != "treasury" || len(got.Tags) != 2 {
+		t.Errorf("object entry = %+v", got)
+	}
+}
+
+func TestParseKeepsNumberPrecision(t *testing.T) {
+	files := map[string]string{
+		"config.yaml": `
+fixed_balances:
+  - {token: BTC, amount: 123456789.123456789123}
+  - token: ETH
+    amount: 2
+evm_networks:
+  - {name: ethereum, chain_id: 1}
+`,
+		"config.toml": `
+# amount = 1.5 in a comment stays as is
+alerts = { rules = [{ type = "value_drop", percent = 12.5 }] }
+
+[[fixed_balances]]
+token = "BTC"
+amount = 123456789.123456789123
+
+[[evm_networks]]
+name = "ethereum"
+chain_id = 1
+
+[[fixed_balances]]
+token = "ETH"
+amount = 2.0
+`,
+	}
+
+	for name, content := range files {
+		cfg, err := Parse(name, []byte(content))
+		if err != nil {
+			t.Fatalf("Parse(%s) error = %v", name, err)
+		}
+		if len(cfg.FixedBalances) != 2 {
+			t.Fatalf("%s: fixed_balances = %+v", name, cfg.FixedBalances)
+		}
+		if got := cfg.FixedBalances[0].Amount.String(); got != "123456789.123456789123" {
+			t.Errorf("%s: amount = %v, want every digit kept", name, got)
+		}
+		if got := cfg.FixedBalances[1].Amount.String(); got != "2" {
+			t.Errorf("%s: amount = %v, want 2", name, got)
+		}
+		if len(cfg.EVMNetworks) != 1 || cfg.EVMNetworks[0].ChainID != 1 {
+			t.Errorf("%s: evm_networks = %+v", name, cfg.EVMNetworks)
+		}
+	}
+
+	cfg, err := Parse("config.toml", []byte(files["config.toml"]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Alerts.Rules) != 1 || cfg.Alerts.Rules[0].Percent != 12.5 {
+		t.Errorf("float in an inline table = %+v", cfg.Alerts.Rules)
+	}
+}
@@ -1,39 +1,241 @@
 package config
 
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/anilcse/cosmoscope/pkg/utils"
+)
+
 type FixedBalance struct {
-	Token  string  `json:"token"`
-	Amount float64 `json:"amount"`
-	Label  string  `json:"label"`
+	Token       string       `json:"token"`
+	Amount      utils.Amount `json:"amount"`
+	Label       string       `json:"label"`
+	CoinGeckoID string       `json:"coingecko_id,omitempty"`
+	Owner       string       `json:"owner,omitempty"`
+	Tags        []string     `json:"tags,omitempty"`
+}
+
+// Address is a configured account. It is written either as a bare address
+// string or as an object that also names the account and who it belongs to.
+type Address struct {
+	Address string   `json:"address"`
+	Label   string   `json:"label,omitempty"`
+	Owner   string   `json:"owner,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+}
+
+func (a *Address) UnmarshalJSON(data []byte) error {
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "\"") {
+		a.Label, a.Owner, a.Tags = "", "", nil
+		return json.Unmarshal(data, &a.Address)
+	}
+
+	type plain Address
+	return json.Unmarshal(data, (*plain)(a))
 }
 
 type Config struct {
-	CosmosNetworks  []string       `json:"cosmos_networks"`
-	EVMNetworks     []EVMNetwork   `json:"evm_networks"`
-	CosmosAddresses []string       `json:"cosmos_addresses"`
-	EVMAddresses    []string       `json:"evm_addresses"`
-	IBCAssetsFile   string         `json:"ibc_assets_file"`
-	MoralisAPIKey   string         `json:"moralis_api_key"`
-	FixedBalances   []FixedBalance `json:"fixed_balances"`
-	CoinGeckoURI    string         `json:"coingecko_uri"`
+	CosmosNetworks     []string       `json:"cosmos_networks"`
+	EVMNetworks        []EVMNetwork   `json:"evm_networks"`
+	CosmosAddresses    []Address      `json:"cosmos_addresses"`
+	ValidatorAddresses []Address      `json:"validator_addresses"`
+	EVMAddresses       []Address      `json:"evm_addresses"`
+	IBCAssetsFile      string         `json:"ibc_assets_file"`
+	MoralisAPIKey      string         `json:"moralis_api_key"`
+	FixedBalances      []FixedBalance `json:"fixed_balances"`
+	CoinGeckoURI       string         `json:"coingecko_uri"`
+	// PriceProviders are consulted in order; a token missing from one
+	// provider is priced by the next. Defaults to CoinGecko via CoinGeckoURI.
+	PriceProviders []PriceProvider `json:"price_providers"`
+	// SnapshotDB is where each run is stored; defaults to ~/.cosmoscope/snapshots.db.
+	SnapshotDB string `json:"snapshot_db"`
+	// Alerts are evaluated after every unfiltered scan.
+	Alerts Alerts `json:"alerts"`
+	// TokenLists are token list files (Uniswap format) whose entries give
+	// the symbol, decimals and CoinGecko ID of EVM tokens by contract.
+	TokenLists []string `json:"token_lists,omitempty"`
+}
+
+// Alert rule types.
+const (
+	AlertValueDrop    = "value_drop"
+	AlertRewardsAbove = "rewards_above"
+	AlertBalanceBelow = "balance_below"
+	AlertNewToken     = "new_token"
+)
+
+// Alert sink types.
+const (
+	SinkWebhook = "webhook"
+	SinkSlack   = "slack"
+	SinkEmail   = "email"
+)
+
+type Alerts struct {
+	Rules []AlertRule `json:"rules"`
+	Sinks []AlertSink `json:"sinks"`
+}
+
+// AlertRule is one condition checked against the scanned balances.
+//
+//   - value_drop: the total value fell by at least Percent since the last snapshot
+//   - rewards_above: unclaimed rewards are worth more than Threshold USD, or
+//     exceed Threshold tokens when Token is set
+//   - balance_below: the amount of Token held is below Threshold
+//   - new_token: a token that was not in the last snapshot is held
+//
+// Network optionally limits a rule to one chain ("cosmoshub") or one full
+// network name ("cosmoshub-staking").
+type AlertRule struct {
+	Name      string       `json:"name,omitempty"`
+	Type      string       `json:"type"`
+	Percent   float64      `json:"percent,omitempty"`
+	Threshold utils.Amount `json:"threshold,omitempty"`
+	Token     string       `json:"token,omitempty"`
+	Network   string       `json:"network,omitempty"`
+}
+
+// AlertSink is where triggered alerts are delivered. Webhook and Slack sinks
+// post to URL; email sinks send through an SMTP server.
+type AlertSink struct {
+	Type     string   `json:"type"`
+	URL      string   `json:"url,omitempty"`
+	SMTPHost string   `json:"smtp_host,omitempty"`
+	SMTPPort int      `json:"smtp_port,omitempty"`
+	Username string   `json:"username,omitempty"`
+	Password string   `json:"password,omitempty"`
+	From     string   `json:"from,omitempty"`
+	To       []string `json:"to,omitempty"`
+}
+
+type PriceProvider struct {
+	Type   string                 `json:"type"`
+	URL    string                 `json:"url,omitempty"`
+	APIKey string                 `json:"api_key,omitempty"`
+	File   string                 `json:"file,omitempty"`
+	Pools  map[string]OsmosisPool `json:"pools,omitempty"`
+}
+
+// OsmosisPool prices a token from the spot price of a pool against a USD
+// stablecoin.
+type OsmosisPool struct {
+	PoolID        uint64 `json:"pool_id"`
+	BaseDenom     string `json:"base_denom"`
+	BaseDecimals  int    `json:"base_decimals"`
+	QuoteDenom    string `json:"quote_denom"`
+	QuoteDecimals int    `json:"quote_decimals"`
 }
 
 type NativeToken struct {
-	Symbol   string `json:"symbol"`
-	Name     string `json:"name"`
-	Decimals int    `json:"decimals"`
+	Symbol      string `json:"symbol"`
+	Name        string `json:"name"`
+	Decimals    int    `json:"decimals"`
+	CoinGeckoID string `json:"coingecko_id,omitempty"`
 }
 
 type EVMNetwork struct {
-	Name        string      `json:"name"`
-	RPC         string      `json:"rpc"`
-	ChainID     int         `json:"chain_id"`
-	NativeToken NativeToken `json:"native_token"`
+	Name         string            `json:"name"`
+	RPC          string            `json:"rpc"`
+	ChainID      int               `json:"chain_id"`
+	NativeToken  NativeToken       `json:"native_token"`
+	CoinGeckoIDs map[string]string `json:"coingecko_ids,omitempty"`
+	// IndexerChain is the chain identifier Moralis uses for this network,
+	// such as "base". It defaults to the built-in name for ChainID.
+	IndexerChain string `json:"indexer_chain,omitempty"`
+	// Tokens are queried directly from the RPC through Multicall3 instead
+	// of through Moralis.
+	Tokens []EVMToken `json:"tokens,omitempty"`
+	// Multicall overrides the Multicall3 contract address for chains where
+	// it is not deployed at the usual address.
+	Multicall string `json:"multicall,omitempty"`
+	// UnlistedTokens decides what happens to Moralis tokens that are not on
+	// a token list: "filter" (the default) applies the spam policy,
+	// "include" keeps them and "exclude" drops them.
+	UnlistedTokens string      `json:"unlisted_tokens,omitempty"`
+	SpamPolicy     TokenPolicy `json:"spam_policy,omitempty"`
+}
+
+// TokenPolicy decides which Moralis tokens are treated as spam.
+type TokenPolicy struct {
+	// Allow and Deny are contract addresses that are always kept or always
+	// dropped, whatever the token lists and heuristics say.
+	Allow []string `json:"allow,omitempty"`
+	Deny  []string `json:"deny,omitempty"`
+	// Heuristics turns individual heuristics on or off by name; every
+	// heuristic is on unless set to false.
+	Heuristics map[string]bool `json:"heuristics,omitempty"`
+	// MinSecurityScore drops tokens whose Moralis security score is lower.
+	MinSecurityScore int `json:"min_security_score,omitempty"`
+}
+
+// Spam heuristics.
+const (
+	// HeuristicPossibleSpam drops tokens Moralis flags as possible spam.
+	HeuristicPossibleSpam = "possible_spam"
+	// HeuristicSuspiciousName drops tokens whose name or symbol looks like
+	// an advert, such as a URL or "claim".
+	HeuristicSuspiciousName = "suspicious_name"
+	// HeuristicUnverified drops unverified contracts without a security score.
+	HeuristicUnverified = "unverified"
+)
+
+// Enabled reports whether heuristic is switched on.
+func (p TokenPolicy) Enabled(heuristic string) bool {
+	enabled, set := p.Heuristics[heuristic]
+	return !set || enabled
+}
+
+// Policies for tokens missing from the token lists.
+const (
+	UnlistedFilter  = "filter"
+	UnlistedInclude = "include"
+	UnlistedExclude = "exclude"
+)
+
+// EVMToken is an ERC-20 contract. Symbol and decimals are read from the
+// contract when they are not given.
+type EVMToken struct {
+	Contract string `json:"contract"`
+	Symbol   string `json:"symbol,omitempty"`
+	// Decimals is nil when not configured; zero is a valid value.
+	Decimals    *int   `json:"decimals,omitempty"`
+	CoinGeckoID string `json:"coingecko_id,omitempty"`
+}
+
+// TokenList is a token list in the Uniswap format
+// (https://tokenlists.org).
+type TokenList struct {
+	Name   string           `json:"name"`
+	Tokens []TokenListEntry `json:"tokens"`
+}
+
+type TokenListEntry struct {
+	ChainID    int                    `json:"chainId"`
+	Address    string                 `json:"address"`
+	Name       string                 `json:"name"`
+	Symbol     string                 `json:"symbol"`
+	Decimals   int                    `json:"decimals"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// CoinGeckoID returns the coingeckoId extension, which CoinGecko-derived
+// lists carry, if the entry has one.
+func (e TokenListEntry) CoinGeckoID() string {
+	for _, key := range []string{"coingeckoId", "coingecko_id"} {
+		if id, ok := e.Extensions[key].(string); ok {
+			return id
+		}
+	}
+	return ""
 }
 
 type IBCAsset struct {
-	Type     string `json:"type"`
-	Denom    string `json:"denom"`
-	Name     string `json:"name"`
-	Symbol   string `json:"symbol"`
-	Decimals int    `json:"decimals"`
+	Type        string `json:"type"`
+	Denom       string `json:"denom"`
+	Name        string `json:"name"`
+	Symbol      string `json:"symbol"`
+	Decimals    int    `json:"decimals"`
+	CoinGeckoID string `json:"coingecko_id,omitempty"`
+	Network     string `json:"network,omitempty"`
 }
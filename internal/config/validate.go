@@ -0,0 +1,537 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Problem is one thing wrong with a configuration file. Line is zero when
+// the problem cannot be tied to a line, e.g. a missing field.
+type Problem struct {
+	Line    int
+	Field   string
+	Message string
+	// Warning marks problems that do not stop cosmoscope from running.
+	Warning bool
+}
+
+// String formats the problem without its line, which callers prefix along
+// with the file name.
+func (p Problem) String() string {
+	var b strings.Builder
+	if p.Field != "" {
+		fmt.Fprintf(&b, "%s: ", p.Field)
+	}
+	if p.Warning {
+		b.WriteString("warning: ")
+	}
+	b.WriteString(p.Message)
+	return b.String()
+}
+
+// Validator checks a configuration file. The network checks are optional;
+// when nil the corresponding remote check is skipped.
+type Validator struct {
+	// CheckChain returns an error if a Cosmos network is not in the chain
+	// registry.
+	CheckChain func(name string) error
+	// RPCChainID returns the chain id served by an EVM RPC endpoint.
+	RPCChainID func(rpc string) (int64, error)
+	// IndexerChain reports whether Moralis indexes an EVM network.
+	IndexerChain func(network EVMNetwork) (string, bool)
+}
+
+// Validate reads the config file at path and returns every problem found.
+// The error is only set when the file cannot be read.
+func (v Validator) Validate(path string) ([]Problem, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading config file: %v", err)
+	}
+
+	c := &validation{data: data}
+
+	// Line references are only available for JSON files.
+	if ext := strings.ToLower(filepath.Ext(path)); ext != ".yaml" && ext != ".yml" && ext != ".toml" {
+		var probe interface{}
+		if err := json.Unmarshal(data, &probe); err != nil {
+			c.decodeError(err)
+			return c.problems, nil
+		}
+		c.offsets = fieldOffsets(data)
+	}
+
+	doc, err := loadDocument(path, data)
+	if err != nil {
+		c.problems = append(c.problems, Problem{Message: err.Error()})
+		return c.problems, nil
+	}
+	c.unknownFields("", doc, reflect.TypeOf(Config{}))
+
+	var cfg Config
+	if err := fromDocument(doc, &cfg); err != nil {
+		c.decodeError(err)
+		return c.problems, nil
+	}
+	resolvePaths(&cfg, filepath.Dir(path))
+
+	c.checkCosmos(cfg, v.CheckChain)
+	c.checkEVM(cfg, v.RPCChainID, v.IndexerChain)
+	c.checkPricing(cfg)
+	c.checkAlerts(cfg)
+	c.checkFiles(cfg)
+
+	sort.SliceStable(c.problems, func(i, j int) bool {
+		return c.problems[i].Line < c.problems[j].Line
+	})
+	return c.problems, nil
+}
+
+type validation struct {
+	data     []byte
+	offsets  map[string]int64
+	problems []Problem
+}
+
+func (c *validation) add(field, format string, args ...interface{}) {
+	c.problems = append(c.problems, Problem{Line: c.line(field), Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (c *validation) warn(field, format string, args ...interface{}) {
+	c.problems = append(c.problems, Problem{Line: c.line(field), Field: field, Message: fmt.Sprintf(format, args...), Warning: true})
+}
+
+// line returns the line of field, or of its nearest parent that is present
+// in the file.
+func (c *validation) line(field string) int {
+	for field != "" {
+		if offset, ok := c.offsets[field]; ok {
+			return lineAt(c.data, offset)
+		}
+		cut := strings.LastIndexAny(field, ".[")
+		if cut < 0 {
+			break
+		}
+		field = field[:cut]
+	}
+	return 0
+}
+
+func (c *validation) decodeError(err error) {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		c.problems = append(c.problems, Problem{Line: lineAt(c.data, syntaxErr.Offset), Message: "invalid JSON: " + syntaxErr.Error()})
+	case errors.As(err, &typeErr):
+		c.problems = append(c.problems, Problem{
+			Line:    c.line(typeErr.Field),
+			Field:   typeErr.Field,
+			Message: fmt.Sprintf("expected %s, got JSON %s", typeErr.Type, typeErr.Value),
+		})
+	default:
+		c.problems = append(c.problems, Problem{Message: err.Error()})
+	}
+}
+
+// unknownFields reports object keys that do not map to a field of t, which
+// are usually typos that json.Unmarshal silently ignores.
+func (c *validation) unknownFields(path string, value interface{}, t reflect.Type) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		switch t.Kind() {
+		case reflect.Struct:
+			fields := make(map[string]reflect.Type)
+			for i := 0; i < t.NumField(); i++ {
+				name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+				fields[name] = t.Field(i).Type
+			}
+			for key, child := range v {
+				fieldType, ok := fields[key]
+				if !ok {
+					c.add(joinPath(path, key), "unknown field")
+					continue
+				}
+				c.unknownFields(joinPath(path, key), child, fieldType)
+			}
+		case reflect.Map:
+			for key, child := range v {
+				c.unknownFields(joinPath(path, key), child, t.Elem())
+			}
+		}
+	case []interface{}:
+		if t.Kind() == reflect.Slice {
+			for i, child := range v {
+				c.unknownFields(fmt.Sprintf("%s[%d]", path, i), child, t.Elem())
+			}
+		}
+	}
+}
+
+func (c *validation) checkCosmos(cfg Config, checkChain func(string) error) {
+	for i, name := range cfg.CosmosNetworks {
+		field := fmt.Sprintf("cosmos_networks[%d]", i)
+		if name == "" {
+			c.add(field, "network name is empty")
+			continue
+		}
+		if checkChain != nil {
+			if err := checkChain(name); err != nil {
+				c.add(field, "%q is not a chain registry directory: %v", name, err)
+			}
+		}
+	}
+
+	if len(cfg.CosmosAddresses) > 0 && len(cfg.CosmosNetworks) == 0 {
+		c.warn("cosmos_addresses", "addresses are configured but cosmos_networks is empty")
+	}
+	for i, address := range cfg.CosmosAddresses {
+		c.checkBech32(c.addressField("cosmos_addresses", i), address.Address)
+	}
+	for i, address := range cfg.ValidatorAddresses {
+		c.checkBech32(c.addressField("validator_addresses", i), address.Address)
+	}
+}
+
+// addressField names the address of entry i in list, which is the entry
+// itself for bare strings and its "address" key for objects.
+func (c *validation) addressField(list string, i int) string {
+	field := fmt.Sprintf("%s[%d]", list, i)
+	if _, ok := c.offsets[field+".address"]; ok {
+		return field + ".address"
+	}
+	return field
+}
+
+func (c *validation) checkBech32(field, address string) {
+	if address == "" {
+		c.add(field, "address is empty")
+		return
+	}
+	if _, _, err := bech32.DecodeAndConvert(address); err != nil {
+		c.add(field, "%q is not a valid bech32 address: %v", address, err)
+	}
+}
+
+func (c *validation) checkEVM(cfg Config, rpcChainID func(string) (int64, error), indexerChain func(EVMNetwork) (string, bool)) {
+	if cfg.MoralisAPIKey == "" {
+		for _, network := range cfg.EVMNetworks {
+			if len(network.Tokens) == 0 {
+				c.add("moralis_api_key", "required for evm_networks without a tokens list")
+				break
+			}
+		}
+	}
+
+	names := make(map[string]bool)
+	for i, network := range cfg.EVMNetworks {
+		field := fmt.Sprintf("evm_networks[%d]", i)
+		if network.Name == "" {
+			c.add(field+".name", "network name is empty")
+		} else if names[network.Name] {
+			c.add(field+".name", "network %q is listed more than once", network.Name)
+		}
+		names[network.Name] = true
+
+		if network.ChainID <= 0 {
+			c.add(field+".chain_id", "chain_id must be a positive number")
+		}
+		if network.NativeToken.Symbol == "" {
+			c.add(field+".native_token.symbol", "native token symbol is empty")
+		}
+		if network.NativeToken.Decimals <= 0 {
+			c.add(field+".native_token.decimals", "native token decimals must be positive")
+		}
+		for contract := range network.CoinGeckoIDs {
+			if !common.IsHexAddress(contract) {
+				c.add(field+".coingecko_ids."+contract, "%q is not a contract address", contract)
+			}
+		}
+		for j, token := range network.Tokens {
+			if !common.IsHexAddress(token.Contract) {
+				c.add(fmt.Sprintf("%s.tokens[%d].contract", field, j), "%q is not a contract address", token.Contract)
+			}
+			if token.Decimals != nil && *token.Decimals < 0 {
+				c.add(fmt.Sprintf("%s.tokens[%d].decimals", field, j), "decimals must not be negative")
+			}
+		}
+		if network.Multicall != "" && !common.IsHexAddress(network.Multicall) {
+			c.add(field+".multicall", "%q is not a contract address", network.Multicall)
+		}
+		switch network.UnlistedTokens {
+		case "", UnlistedFilter, UnlistedInclude, UnlistedExclude:
+		default:
+			c.add(field+".unlisted_tokens", "must be filter, include or exclude")
+		}
+		if network.UnlistedTokens != "" && len(cfg.TokenLists) == 0 {
+			c.warn(field+".unlisted_tokens", "no token_lists are configured, so every token is unlisted")
+		}
+		c.checkTokenPolicy(field+".spam_policy", network.SpamPolicy)
+		if indexerChain != nil && len(network.Tokens) == 0 {
+			if _, ok := indexerChain(network); !ok {
+				c.warn(field+".chain_id", "chain %d is unsupported by the Moralis indexer; set indexer_chain or list its tokens", network.ChainID)
+			}
+		}
+
+		if !c.checkURL(field+".rpc", network.RPC, "http", "https", "ws", "wss") {
+			continue
+		}
+		if rpcChainID != nil {
+			chainID, err := rpcChainID(network.RPC)
+			switch {
+			case err != nil:
+				c.add(field+".rpc", "could not query eth_chainId: %v", err)
+			case chainID != int64(network.ChainID):
+				c.add(field+".chain_id", "chain_id is %d but the RPC reports %d", network.ChainID, chainID)
+			}
+		}
+	}
+
+	for i, entry := range cfg.EVMAddresses {
+		field := c.addressField("evm_addresses", i)
+		address := entry.Address
+		if !common.IsHexAddress(address) {
+			c.add(field, "%q is not a valid EVM address", address)
+			continue
+		}
+		checksummed := common.HexToAddress(address).Hex()
+		if address == checksummed {
+			continue
+		}
+		hex := strings.TrimPrefix(address, "0x")
+		if hex == strings.ToLower(hex) || hex == strings.ToUpper(hex) {
+			c.warn(field, "address is not checksummed, use %s", checksummed)
+		} else {
+			c.add(field, "invalid checksum, expected %s", checksummed)
+		}
+	}
+}
+
+func (c *validation) checkTokenPolicy(field string, policy TokenPolicy) {
+	for i, contract := range policy.Allow {
+		if !common.IsHexAddress(contract) {
+			c.add(fmt.Sprintf("%s.allow[%d]", field, i), "%q is not a contract address", contract)
+		}
+	}
+	for i, contract := range policy.Deny {
+		if !common.IsHexAddress(contract) {
+			c.add(fmt.Sprintf("%s.deny[%d]", field, i), "%q is not a contract address", contract)
+		}
+	}
+	for name := range policy.Heuristics {
+		switch name {
+		case HeuristicPossibleSpam, HeuristicSuspiciousName, HeuristicUnverified:
+		default:
+			c.add(field+".heuristics."+name, "unknown heuristic")
+		}
+	}
+	if policy.MinSecurityScore < 0 || policy.MinSecurityScore > 100 {
+		c.add(field+".min_security_score", "must be between 0 and 100")
+	}
+}
+
+func (c *validation) checkPricing(cfg Config) {
+	if cfg.CoinGeckoURI != "" {
+		c.checkURL("coingecko_uri", cfg.CoinGeckoURI, "http", "https")
+	}
+
+	for i, provider := range cfg.PriceProviders {
+		field := fmt.Sprintf("price_providers[%d]", i)
+		if provider.URL != "" {
+			c.checkURL(field+".url", provider.URL, "http", "https")
+		}
+
+		switch strings.ToLower(provider.Type) {
+		case "coingecko":
+		case "coinmarketcap":
+			if provider.APIKey == "" {
+				c.add(field+".api_key", "required for coinmarketcap")
+			}
+		case "osmosis":
+			if len(provider.Pools) == 0 {
+				c.add(field+".pools", "osmosis needs at least one pool")
+			}
+			for symbol, pool := range provider.Pools {
+				if pool.PoolID == 0 || pool.BaseDenom == "" || pool.QuoteDenom == "" {
+					c.add(field+".pools."+symbol, "pool_id, base_denom and quote_denom are required")
+				}
+			}
+		case "static":
+			if provider.File == "" {
+				c.add(field+".file", "required for static prices")
+			} else if _, err := os.Stat(provider.File); err != nil {
+				c.add(field+".file", "%v", err)
+			}
+		default:
+			c.add(field+".type", "unknown price provider type %q", provider.Type)
+		}
+	}
+
+	for i, fixed := range cfg.FixedBalances {
+		field := fmt.Sprintf("fixed_balances[%d]", i)
+		if fixed.Token == "" {
+			c.add(field+".token", "token is empty")
+		}
+		if fixed.Amount.Sign() < 0 {
+			c.add(field+".amount", "amount is negative")
+		}
+	}
+}
+
+func (c *validation) checkAlerts(cfg Config) {
+	for i, rule := range cfg.Alerts.Rules {
+		field := fmt.Sprintf("alerts.rules[%d]", i)
+		switch rule.Type {
+		case AlertValueDrop:
+			if rule.Percent <= 0 || rule.Percent > 100 {
+				c.add(field+".percent", "must be between 0 and 100")
+			}
+		case AlertRewardsAbove:
+			if rule.Threshold.Sign() <= 0 {
+				c.add(field+".threshold", "must be positive")
+			}
+		case AlertBalanceBelow:
+			if rule.Token == "" {
+				c.add(field+".token", "required for balance_below")
+			}
+			if rule.Threshold.Sign() <= 0 {
+				c.add(field+".threshold", "must be positive")
+			}
+		case AlertNewToken:
+		default:
+			c.add(field+".type", "unknown alert type %q", rule.Type)
+		}
+	}
+
+	if len(cfg.Alerts.Rules) > 0 && len(cfg.Alerts.Sinks) == 0 {
+		c.warn("alerts.sinks", "alerts are only printed without a sink")
+	}
+	for i, sink := range cfg.Alerts.Sinks {
+		field := fmt.Sprintf("alerts.sinks[%d]", i)
+		switch sink.Type {
+		case SinkWebhook, SinkSlack:
+			if sink.URL == "" {
+				c.add(field+".url", "required for %s sinks", sink.Type)
+			} else {
+				c.checkURL(field+".url", sink.URL, "http", "https")
+			}
+		case SinkEmail:
+			if sink.SMTPHost == "" {
+				c.add(field+".smtp_host", "required for email sinks")
+			}
+			if sink.From == "" {
+				c.add(field+".from", "required for email sinks")
+			}
+			if len(sink.To) == 0 {
+				c.add(field+".to", "required for email sinks")
+			}
+		default:
+			c.add(field+".type", "unknown sink type %q", sink.Type)
+		}
+	}
+}
+
+func (c *validation) checkFiles(cfg Config) {
+	if cfg.IBCAssetsFile != "" {
+		if _, err := LoadIBCAssets(cfg.IBCAssetsFile); err != nil {
+			c.add("ibc_assets_file", "%v", err)
+		}
+	}
+	for i, path := range cfg.TokenLists {
+		if _, err := LoadTokenLists([]string{path}); err != nil {
+			c.add(fmt.Sprintf("token_lists[%d]", i), "%v", err)
+		}
+	}
+}
+
+func (c *validation) checkURL(field, raw string, schemes ...string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		c.add(field, "invalid URL: %v", err)
+		return false
+	}
+	for _, scheme := range schemes {
+		if u.Scheme == scheme && u.Host != "" {
+			return true
+		}
+	}
+	c.add(field, "%q is not a %s URL", raw, strings.Join(schemes, "/"))
+	return false
+}
+
+// fieldOffsets maps the path of every value in a JSON document, such as
+// "evm_networks[0].rpc", to the offset where the value starts.
+func fieldOffsets(data []byte) map[string]int64 {
+	offsets := make(map[string]int64)
+	decoder := json.NewDecoder(bytes.NewReader(data))
+
+	var walk func(path string) error
+	walk = func(path string) error {
+		offsets[path] = valueStart(data, decoder.InputOffset())
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+
+		switch token {
+		case json.Delim('{'):
+			for decoder.More() {
+				key, err := decoder.Token()
+				if err != nil {
+					return err
+				}
+				if err := walk(joinPath(path, fmt.Sprint(key))); err != nil {
+					return err
+				}
+			}
+			_, err = decoder.Token()
+		case json.Delim('['):
+			for i := 0; decoder.More(); i++ {
+				if err := walk(fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+			_, err = decoder.Token()
+		}
+		return err
+	}
+
+	_ = walk("")
+	return offsets
+}
+
+// valueStart skips the separators between the previous token and a value.
+func valueStart(data []byte, offset int64) int64 {
+	for offset < int64(len(data)) && strings.IndexByte(" \t\r\n:,", data[offset]) >= 0 {
+		offset++
+	}
+	return offset
+}
+
+func lineAt(data []byte, offset int64) int {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	path := writeConfig(t, `{
+    "cosmos_networks": ["cosmoshub", "notachain"],
+    "cosmos_addresses": ["cosmos1invalid"],
+    "evm_networks": [
+        {
+            "name": "ethereum",
+            "rpc": "https://rpc.example.com",
+            "chain_id": 5,
+            "native_token": {"symbol": "ETH", "decimals": 18}
+        }
+    ],
+    "evm_addresses": ["0x40fd27a96cdbffc90ab3b83bf695911426a69fd5"],
+    "coingecko_uri": "not a url",
+    "moralis_key": "typo"
+}`)
+
+	validator := Validator{
+		CheckChain: func(name string) error {
+			if name == "notachain" {
+				return errors.New("not found")
+			}
+			return nil
+		},
+		RPCChainID: func(string) (int64, error) { return 1, nil },
+	}
+	problems, err := validator.Validate(path)
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	want := map[string]int{
+		"cosmos_networks[1]":       2,
+		"cosmos_addresses[0]":      3,
+		"evm_networks[0].chain_id": 8,
+		"evm_addresses[0]":         12,
+		"coingecko_uri":            13,
+		"moralis_key":              14,
+		"moralis_api_key":          0,
+	}
+	found := make(map[string]bool)
+	for _, problem := range problems {
+		line, ok := want[problem.Field]
+		if !ok {
+			t.Errorf("unexpected problem %v", problem)
+			continue
+		}
+		if problem.Line != line {
+			t.Errorf("%s reported on line %d, want %d", problem.Field, problem.Line, line)
+		}
+		found[problem.Field] = true
+	}
+	for field := range want {
+		if !found[field] {
+			t.Errorf("no problem reported for %s", field)
+		}
+	}
+}
+
+func TestValidateSyntaxError(t *testing.T) {
+	path := writeConfig(t, "{\n  \"cosmos_networks\": [\"cosmoshub\",]\n}")
+
+	problems, err := Validator{}.Validate(path)
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if len(problems) != 1 || problems[0].Line != 2 || !strings.Contains(problems[0].Message, "invalid JSON") {
+		t.Errorf("Validate() = %v, want one JSON error on line 2", problems)
+	}
+}
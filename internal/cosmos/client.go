@@ -5,24 +5,33 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
+	"os"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/anilcse/cosmoscope/internal/config"
+	"github.com/anilcse/cosmoscope/internal/metrics"
 	"github.com/anilcse/cosmoscope/internal/portfolio"
-	"github.com/anilcse/cosmoscope/internal/price"
 	"github.com/anilcse/cosmoscope/pkg/utils"
 	"github.com/cosmos/cosmos-sdk/types/bech32"
 )
 
 // Cache for chain and asset information
 var (
-	chainInfoCache  = make(map[string]*ChainInfo)
-	assetListCache  = make(map[string]AssetList)
-	registryBaseURL = "https://raw.githubusercontent.com/cosmos/chain-registry/master"
-	cacheMutex      sync.RWMutex
+	chainInfoCache    = make(map[string]*ChainInfo)
+	assetListCache    = make(map[string]AssetList)
+	bondDenomCache    = make(map[string]string)
+	endpointCache     = make(map[string]string)
+	denomTraceCache   = make(map[string]resolvedDenom)
+	chainNameCache    = make(map[string]string)
+	unresolvedDenoms  = make(map[string]struct{})
+	assetOverrides    map[string]*config.IBCAsset
+	registryBaseURL   = "https://raw.githubusercontent.com/cosmos/chain-registry/master"
+	chainDirectoryURL = "https://chains.cosmos.directory"
+	cacheMutex        sync.RWMutex
 )
 
 func FetchChainInfo(network string) (*ChainInfo, error) {
@@ -39,10 +48,16 @@ func FetchChainInfo(network string) (*ChainInfo, error) {
 	//nolint:gosec // G107: url is constructed from trusted base URL and sanitized network name
 	resp, err := http.Get(url)
 	if err != nil {
+		metrics.RecordFailure(url, "chain_info")
 		return nil, fmt.Errorf("error fetching chain info: %v", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		metrics.RecordFailure(url, "chain_info")
+		return nil, fmt.Errorf("chain %s not found in the chain registry (status %d)", network, resp.StatusCode)
+	}
+
 	var chainInfo ChainInfo
 	if err := json.NewDecoder(resp.Body).Decode(&chainInfo); err != nil {
 		return nil, fmt.Errorf("error decoding chain info: %v", err)
@@ -86,118 +101,474 @@ func fetchAssetList(network string) (*AssetList, error) {
 	return &assetList, nil
 }
 
-func resolveSymbolForDenom(network, denom string) (string, int) {
+// SetAssetOverrides installs locally configured asset metadata that takes
+// precedence over the chain registry.
+func SetAssetOverrides(overrides map[string]*config.IBCAsset) {
+	cacheMutex.Lock()
+	assetOverrides = overrides
+	cacheMutex.Unlock()
+}
+
+// UnresolvedDenoms returns every "network:denom" pair whose symbol and
+// decimals had to be guessed.
+func UnresolvedDenoms() []string {
+	cacheMutex.RLock()
+	defer cacheMutex.RUnlock()
+
+	denoms := make([]string, 0, len(unresolvedDenoms))
+	for denom := range unresolvedDenoms {
+		denoms = append(denoms, denom)
+	}
+	sort.Strings(denoms)
+	return denoms
+}
+
+func markUnresolved(network, denom string) {
+	cacheMutex.Lock()
+	unresolvedDenoms[network+":"+denom] = struct{}{}
+	cacheMutex.Unlock()
+}
+
+// findAssetOverride returns the override for denom on network, preferring
+// an entry for that network over one that applies to every network.
+func findAssetOverride(network, denom string) (*config.IBCAsset, bool) {
+	cacheMutex.RLock()
+	defer cacheMutex.RUnlock()
+
+	if asset, exists := assetOverrides[config.AssetKey(network, denom)]; exists {
+		return asset, true
+	}
+	asset, exists := assetOverrides[config.AssetKey("", denom)]
+	return asset, exists
+}
+
+// denomInfo is the display metadata of a denom.
+type denomInfo struct {
+	symbol      string
+	decimals    int
+	coingeckoID string
+}
+
+func resolveDenom(network, denom string) denomInfo {
+	if asset, ok := findAssetOverride(network, denom); ok {
+		return denomInfo{symbol: asset.Symbol, decimals: asset.Decimals, coingeckoID: asset.CoinGeckoID}
+	}
+
 	assetList, err := fetchAssetList(network)
 
 	if err != nil {
+		markUnresolved(network, denom)
 		// Fallback to basic resolution if asset list fetch fails
 		if strings.HasPrefix(denom, "ibc/") {
-			return denom + " (Unknown IBC Asset)", 6
+			return denomInfo{symbol: denom + " (Unknown IBC Asset)", decimals: 6}
 		}
 		if strings.HasPrefix(denom, "u") {
-			return strings.ToUpper(strings.TrimLeft(denom, "u")), 6
+			return denomInfo{symbol: strings.ToUpper(strings.TrimLeft(denom, "u")), decimals: 6}
 		}
 		if strings.HasPrefix(denom, "a") {
-			return strings.ToUpper(strings.TrimLeft(denom, "a")), 18
+			return denomInfo{symbol: strings.ToUpper(strings.TrimLeft(denom, "a")), decimals: 18}
 		}
-		return denom, 6
+		return denomInfo{symbol: denom, decimals: 6}
+	}
+
+	if info, ok := findAsset(assetList, denom); ok {
+		return info
 	}
 
+	// The chain's asset list does not know every IBC hash, so follow the
+	// denom trace back to the origin chain's registry entry.
+	if strings.HasPrefix(denom, "ibc/") {
+		if info, err := resolveIBCDenom(network, denom); err == nil {
+			return info
+		}
+	}
+
+	// Fallback if asset not found in registry
+	markUnresolved(network, denom)
+	return denomInfo{symbol: denom, decimals: 6}
+}
+
+func findAsset(assetList *AssetList, denom string) (denomInfo, bool) {
 	for _, asset := range assetList.Assets {
 		if asset.Base == denom {
+			info := denomInfo{symbol: asset.Symbol, decimals: 6, coingeckoID: asset.CoingeckoID}
+
 			// Find the decimal by looking for the display denom in denom_units
 			for _, denomUnit := range asset.DenomUnits {
 				if denomUnit.Denom == asset.Display {
-					return asset.Symbol, denomUnit.Exponent
+					info.decimals = denomUnit.Exponent
+					return info, true
 				}
 			}
 
 			// Fallback to 6 decimals if no denom_units found
-			return asset.Symbol, 6
+			return info, true
 		}
 	}
+	return denomInfo{}, false
+}
 
-	// Fallback if asset not found in registry
-	return denom, 6
+// ActiveEndpoint returns the REST endpoint used to query network.
+func ActiveEndpoint(network string) (string, error) {
+	return activeEndpointFor(network)
 }
 
-func QueryBalances(networkName string, address string, balanceChan chan<- portfolio.Balance) {
-	chainInfo, err := FetchChainInfo(networkName)
+// activeEndpointFor returns a responsive REST endpoint for network. The
+// selection is cached so repeated lookups do not probe every endpoint again.
+func activeEndpointFor(network string) (string, error) {
+	cacheMutex.RLock()
+	endpoint, exists := endpointCache[network]
+	cacheMutex.RUnlock()
+	if exists {
+		return endpoint, nil
+	}
+
+	chainInfo, err := FetchChainInfo(network)
 	if err != nil {
-		fmt.Printf("Error fetching chain info for %s: %v\n", networkName, err)
-		return
+		return "", err
 	}
 
 	// Select active REST endpoint
 	if len(chainInfo.APIs.REST) == 0 {
-		fmt.Printf("No REST endpoints available for %s\n", networkName)
-		return
+		return "", fmt.Errorf("no REST endpoints available for %s", network)
 	}
 
-	apiEndpoint := getActiveEndpoint(chainInfo.APIs.REST)
-	if apiEndpoint == "" {
-		fmt.Printf("No active REST endpoints found for %s\n", networkName)
+	endpoint = getActiveEndpoint(chainInfo.APIs.REST)
+	if endpoint == "" {
+		metrics.RecordFailure(chainInfo.APIs.REST[0].Address, "endpoint_selection")
+		return "", fmt.Errorf("no active REST endpoints found for %s", network)
+	}
+
+	cacheMutex.Lock()
+	endpointCache[network] = endpoint
+	cacheMutex.Unlock()
+	metrics.RecordEndpointSelection(network, endpoint)
+
+	return endpoint, nil
+}
+
+// dropEndpoint forgets every cached REST endpoint that rawURL was sent to,
+// so the next query for that network selects a working endpoint again.
+func dropEndpoint(rawURL string) {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	for network, endpoint := range endpointCache {
+		if strings.HasPrefix(rawURL, endpoint) {
+			delete(endpointCache, network)
+		}
+	}
+}
+
+func QueryBalances(networkName string, address string, balanceChan chan<- portfolio.Balance) {
+	apiEndpoint, err := activeEndpointFor(networkName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error selecting REST endpoint for %s: %v\n", networkName, err)
 		return
 	}
 
+	// An address that never received a transaction on the chain has no
+	// account, so it cannot be vesting either.
+	vesting, err := queryVesting(apiEndpoint, address, time.Now())
+	if err != nil && !isNotFound(err) {
+		fmt.Fprintf(os.Stderr, "Error fetching account for %s: %v\n", address, err)
+		metrics.RecordFailure(apiEndpoint, "account")
+	}
+
 	// Query bank balances
 	bankBalances := getBalance(apiEndpoint, address, "/cosmos/bank/v1beta1/balances")
 	for _, balance := range bankBalances {
-		symbol, decimals := resolveSymbolForDenom(networkName, balance.Denom)
-		amount := utils.ParseAmount(balance.Amount, decimals)
-		usdValue := price.CalculateUSDValue(symbol, amount)
+		info := resolveDenom(networkName, balance.Denom)
+		amount := utils.ParseAmount(balance.Amount, info.decimals)
+		if vesting != nil {
+			amount = splitVestingBalance(networkName, address, balance.Denom, amount, vesting, balanceChan)
+		}
 
 		balanceChan <- portfolio.Balance{
 			Network:  fmt.Sprintf("%s-bank", networkName),
 			Account:  address,
 			HexAddr:  getHexAddress(address),
-			Token:    symbol,
+			Token:    info.symbol,
+			PriceID:  info.coingeckoID,
 			Amount:   amount,
-			USDValue: usdValue,
-			Decimals: decimals,
+			Decimals: info.decimals,
 		}
 	}
 
-	if len(bankBalances) > 0 {
-		queryStakingBalances(networkName, apiEndpoint, address, balanceChan)
-		queryRewards(networkName, apiEndpoint, address, balanceChan)
+	// An account that staked or unbonded everything has an empty bank
+	// balance, so the staking queries run regardless.
+	redelegating := queryRedelegations(networkName, apiEndpoint, address, balanceChan)
+	queryStakingBalances(networkName, apiEndpoint, address, redelegating, balanceChan)
+	queryUnbondingDelegations(networkName, apiEndpoint, address, balanceChan)
+	queryRewards(networkName, apiEndpoint, address, balanceChan)
+}
+
+// splitVestingBalance reports the still-locked part of a bank balance as a
+// separate vesting balance and returns the spendable remainder.
+func splitVestingBalance(networkName, address, denom string, amount utils.Amount, vesting *vestingState, balanceChan chan<- portfolio.Balance) utils.Amount {
+	info := resolveDenom(networkName, denom)
+	lockedUnits, bankUnlocks := vesting.bankUnlocks(denom, amount.Units(info.decimals))
+	if lockedUnits.Sign() == 0 {
+		return amount
+	}
+	locked := utils.NewAmount(lockedUnits, info.decimals)
+
+	var unlocks []portfolio.Unlock
+	for _, unlock := range bankUnlocks {
+		unlocks = append(unlocks, portfolio.Unlock{
+			Time:   unlock.time,
+			Amount: utils.NewAmount(unlock.amount, info.decimals),
+		})
+	}
+
+	balanceChan <- portfolio.Balance{
+		Network:  fmt.Sprintf("%s-vesting", networkName),
+		Account:  address,
+		HexAddr:  getHexAddress(address),
+		Token:    info.symbol,
+		PriceID:  info.coingeckoID,
+		Amount:   locked,
+		Decimals: info.decimals,
+		Unlocks:  unlocks,
 	}
+
+	return amount.Sub(locked)
 }
 
-func queryStakingBalances(networkName, api, address string, balanceChan chan<- portfolio.Balance) {
-	stakingBalances := getBalance(api, address, "/cosmos/staking/v1beta1/delegations")
-	for _, balance := range stakingBalances {
-		symbol, decimals := resolveSymbolForDenom(networkName, balance.Denom)
-		amount := utils.ParseAmount(balance.Amount, decimals)
-		usdValue := price.CalculateUSDValue(symbol, amount)
+// queryStakingBalances reports bonded delegations. Tokens that are still
+// inside a redelegation window are already reported by queryRedelegations,
+// so they are subtracted from the destination validator's delegation.
+func queryStakingBalances(networkName, api, address string, redelegating map[string]utils.Amount, balanceChan chan<- portfolio.Balance) {
+	var response StakingDelegationResponse
+	url := fmt.Sprintf("%s/cosmos/staking/v1beta1/delegations/%s", api, address)
+	err := fetchAllPages(url, func(body []byte) error {
+		var page StakingDelegationResponse
+		if err := json.Unmarshal(body, &page); err != nil {
+			return err
+		}
+		response.DelegationResponses = append(response.DelegationResponses, page.DelegationResponses...)
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error fetching delegations from %s: %v\n", url, err)
+		metrics.RecordFailure(api, "staking")
+		return
+	}
+
+	for _, delegation := range response.DelegationResponses {
+		units := utils.ParseAmount(delegation.Balance.Amount, 0)
+		validator := delegation.Delegation.ValidatorAddress
+		if moved, ok := redelegating[validator]; ok {
+			units = units.Sub(moved)
+			if units.Sign() <= 0 {
+				continue
+			}
+		}
+
+		category := "staking"
+		if isSelfDelegation(address, validator) {
+			category = "selfbond"
+		}
+
+		info := resolveDenom(networkName, delegation.Balance.Denom)
+		amount := utils.ParseAmount(units.String(), info.decimals)
 
 		balanceChan <- portfolio.Balance{
-			Network:  fmt.Sprintf("%s-staking", networkName),
+			Network:  fmt.Sprintf("%s-%s", networkName, category),
 			Account:  address,
 			HexAddr:  getHexAddress(address),
-			Token:    symbol,
+			Token:    info.symbol,
+			PriceID:  info.coingeckoID,
 			Amount:   amount,
-			USDValue: usdValue,
-			Decimals: decimals,
+			Decimals: info.decimals,
 		}
 	}
 }
 
+// QueryCommission reports the undistributed commission of a validator
+// operator address. Validator entries are tried on every network, so an
+// address that is not a validator on networkName is skipped silently.
+func QueryCommission(networkName string, valoper string, balanceChan chan<- portfolio.Balance) {
+	apiEndpoint, err := activeEndpointFor(networkName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error selecting REST endpoint for %s: %v\n", networkName, err)
+		return
+	}
+
+	var response ValidatorCommissionResponse
+	url := fmt.Sprintf("%s/cosmos/distribution/v1beta1/validators/%s/commission", apiEndpoint, valoper)
+	if err := fetchJSON(url, &response); err != nil {
+		if !isNotFound(err) {
+			fmt.Fprintf(os.Stderr, "Error fetching commission from %s: %v\n", url, err)
+			metrics.RecordFailure(apiEndpoint, "commission")
+		}
+		return
+	}
+
+	for _, coin := range response.Commission.Commission {
+		info := resolveDenom(networkName, coin.Denom)
+		amount := utils.ParseAmount(coin.Amount, info.decimals)
+
+		balanceChan <- portfolio.Balance{
+			Network:  fmt.Sprintf("%s-commission", networkName),
+			Account:  valoper,
+			HexAddr:  getHexAddress(valoper),
+			Token:    info.symbol,
+			PriceID:  info.coingeckoID,
+			Amount:   amount,
+			Decimals: info.decimals,
+		}
+	}
+}
+
+// isSelfDelegation reports whether validator is operated by the key behind
+// the delegator address.
+func isSelfDelegation(delegator, validator string) bool {
+	hexAddr := getHexAddress(delegator)
+	return hexAddr != "" && hexAddr == getHexAddress(validator)
+}
+
+// queryUnbondingDelegations reports every unbonding entry as its own balance
+// so the report can show when each tranche becomes liquid.
+func queryUnbondingDelegations(networkName, api, address string, balanceChan chan<- portfolio.Balance) {
+	var response UnbondingDelegationsResponse
+	url := fmt.Sprintf("%s/cosmos/staking/v1beta1/delegators/%s/unbonding_delegations", api, address)
+	err := fetchAllPages(url, func(body []byte) error {
+		var page UnbondingDelegationsResponse
+		if err := json.Unmarshal(body, &page); err != nil {
+			return err
+		}
+		response.UnbondingResponses = append(response.UnbondingResponses, page.UnbondingResponses...)
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error fetching unbonding delegations from %s: %v\n", url, err)
+		metrics.RecordFailure(api, "unbonding")
+		return
+	}
+	if len(response.UnbondingResponses) == 0 {
+		return
+	}
+
+	bondDenom, err := getBondDenom(networkName, api)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error fetching bond denom for %s: %v\n", networkName, err)
+		return
+	}
+	info := resolveDenom(networkName, bondDenom)
+
+	for _, unbonding := range response.UnbondingResponses {
+		for _, entry := range unbonding.Entries {
+			amount := utils.ParseAmount(entry.Balance, info.decimals)
+			completion := entry.CompletionTime
+			balanceChan <- portfolio.Balance{
+				Network:        fmt.Sprintf("%s-unbonding", networkName),
+				Account:        address,
+				HexAddr:        getHexAddress(address),
+				Token:          info.symbol,
+				PriceID:        info.coingeckoID,
+				Amount:         amount,
+				Decimals:       info.decimals,
+				CompletionTime: &completion,
+			}
+		}
+	}
+}
+
+// queryRedelegations reports tokens that are bonded but still inside a
+// redelegation window. It returns the base-unit amount per destination
+// validator so the caller can avoid counting it twice.
+func queryRedelegations(networkName, api, address string, balanceChan chan<- portfolio.Balance) map[string]utils.Amount {
+	redelegating := make(map[string]utils.Amount)
+
+	var response RedelegationsResponse
+	url := fmt.Sprintf("%s/cosmos/staking/v1beta1/delegators/%s/redelegations", api, address)
+	err := fetchAllPages(url, func(body []byte) error {
+		var page RedelegationsResponse
+		if err := json.Unmarshal(body, &page); err != nil {
+			return err
+		}
+		response.RedelegationResponses = append(response.RedelegationResponses, page.RedelegationResponses...)
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error fetching redelegations from %s: %v\n", url, err)
+		metrics.RecordFailure(api, "redelegations")
+		return redelegating
+	}
+	if len(response.RedelegationResponses) == 0 {
+		return redelegating
+	}
+
+	bondDenom, err := getBondDenom(networkName, api)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error fetching bond denom for %s: %v\n", networkName, err)
+		return redelegating
+	}
+	info := resolveDenom(networkName, bondDenom)
+
+	for _, redelegation := range response.RedelegationResponses {
+		dst := redelegation.Redelegation.ValidatorDstAddress
+		for _, entry := range redelegation.Entries {
+			units := utils.ParseAmount(entry.Balance, 0)
+			redelegating[dst] = redelegating[dst].Add(units)
+
+			amount := utils.ParseAmount(entry.Balance, info.decimals)
+			completion := entry.RedelegationEntry.CompletionTime
+			balanceChan <- portfolio.Balance{
+				Network:        fmt.Sprintf("%s-redelegating", networkName),
+				Account:        address,
+				HexAddr:        getHexAddress(address),
+				Token:          info.symbol,
+				PriceID:        info.coingeckoID,
+				Amount:         amount,
+				Decimals:       info.decimals,
+				CompletionTime: &completion,
+			}
+		}
+	}
+
+	return redelegating
+}
+
+func getBondDenom(network, api string) (string, error) {
+	cacheMutex.RLock()
+	denom, exists := bondDenomCache[network]
+	cacheMutex.RUnlock()
+	if exists {
+		return denom, nil
+	}
+
+	var response StakingParamsResponse
+	if err := fetchJSON(api+"/cosmos/staking/v1beta1/params", &response); err != nil {
+		metrics.RecordFailure(api, "staking_params")
+		return "", err
+	}
+	if response.Params.BondDenom == "" {
+		return "", fmt.Errorf("staking params did not include a bond denom")
+	}
+
+	cacheMutex.Lock()
+	bondDenomCache[network] = response.Params.BondDenom
+	cacheMutex.Unlock()
+
+	return response.Params.BondDenom, nil
+}
+
 func queryRewards(networkName, api, address string, balanceChan chan<- portfolio.Balance) {
 	rewardBalances := getBalance(api, "", fmt.Sprintf("/cosmos/distribution/v1beta1/delegators/%s/rewards", address))
 	for _, balance := range rewardBalances {
-		symbol, decimals := resolveSymbolForDenom(networkName, balance.Denom)
-		amount := utils.ParseAmount(balance.Amount, decimals)
-		usdValue := price.CalculateUSDValue(symbol, amount)
+		info := resolveDenom(networkName, balance.Denom)
+		amount := utils.ParseAmount(balance.Amount, info.decimals)
 
 		balanceChan <- portfolio.Balance{
 			Network:  fmt.Sprintf("%s-rewards", networkName),
 			Account:  address,
 			HexAddr:  getHexAddress(address),
-			Token:    symbol,
+			Token:    info.symbol,
+			PriceID:  info.coingeckoID,
 			Amount:   amount,
-			USDValue: usdValue,
-			Decimals: decimals,
+			Decimals: info.decimals,
 		}
 	}
 }
@@ -211,83 +582,64 @@ func getBalance(api string, address string, endpoint string) []struct {
 		url = fmt.Sprintf("%s%s", api, endpoint)
 	}
 
-	client := &http.Client{Timeout: time.Second * 10}
-	resp, err := client.Get(url)
-	if err != nil {
-		fmt.Printf("Error fetching balance from %s: %v\n", url, err)
-		return nil
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		fmt.Printf("Error reading response body: %v\n", err)
-		return nil
+	var balances []struct {
+		Denom  string `json:"denom"`
+		Amount string `json:"amount"`
 	}
+	rewardMap := make(map[string]utils.Amount)
+
+	err := fetchAllPages(url, func(body []byte) error {
+		switch endpoint {
+		case "/cosmos/bank/v1beta1/balances":
+			var response BankBalanceResponse
+			if err := json.Unmarshal(body, &response); err != nil {
+				return fmt.Errorf("error unmarshaling bank balance response: %s - %s - %s", string(body), address, api)
+			}
+			balances = append(balances, response.Balances...)
 
-	switch endpoint {
-	case "/cosmos/bank/v1beta1/balances":
-		var response BankBalanceResponse
-		if err := json.Unmarshal(body, &response); err != nil {
-			fmt.Printf("Error unmarshaling bank balance response: %s - %s - %s\n", string(body), address, api)
-			return nil
-		}
-		return response.Balances
-
-	case "/cosmos/staking/v1beta1/delegations":
-		var response StakingDelegationResponse
-		if err := json.Unmarshal(body, &response); err != nil {
-			fmt.Printf("Error unmarshaling staking delegation response: %v\n", err)
-			return nil
-		}
-
-		var balances []struct {
-			Denom  string `json:"denom"`
-			Amount string `json:"amount"`
-		}
-
-		for _, delegation := range response.DelegationResponses {
-			balances = append(balances, struct {
-				Denom  string `json:"denom"`
-				Amount string `json:"amount"`
-			}{
-				Denom:  delegation.Balance.Denom,
-				Amount: delegation.Balance.Amount,
-			})
-		}
-		return balances
-
-	default:
-		var response RewardsResponse
-		if err := json.Unmarshal(body, &response); err != nil {
-			fmt.Printf("Error unmarshaling rewards response: %v\n", err)
-			return nil
-		}
+		default:
+			var response RewardsResponse
+			if err := json.Unmarshal(body, &response); err != nil {
+				return fmt.Errorf("error unmarshaling rewards response: %v", err)
+			}
 
-		rewardMap := make(map[string]float64)
-		for _, validatorReward := range response.Rewards {
-			for _, reward := range validatorReward.Reward {
-				amount := utils.ParseAmount(reward.Amount, 0)
-				rewardMap[reward.Denom] += amount
+			for _, validatorReward := range response.Rewards {
+				for _, reward := range validatorReward.Reward {
+					amount := utils.ParseAmount(reward.Amount, 0)
+					rewardMap[reward.Denom] = rewardMap[reward.Denom].Add(amount)
+				}
 			}
 		}
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error fetching balance from %s: %v\n", url, err)
+		query := "rewards"
+		if endpoint == "/cosmos/bank/v1beta1/balances" {
+			query = "bank"
+		}
+		metrics.RecordFailure(api, query)
+		return nil
+	}
 
-		var balances []struct {
+	for denom, amount := range rewardMap {
+		balances = append(balances, struct {
 			Denom  string `json:"denom"`
 			Amount string `json:"amount"`
-		}
+		}{
+			Denom:  denom,
+			Amount: amount.String(),
+		})
+	}
+	return balances
+}
 
-		for denom, amount := range rewardMap {
-			balances = append(balances, struct {
-				Denom  string `json:"denom"`
-				Amount string `json:"amount"`
-			}{
-				Denom:  denom,
-				Amount: fmt.Sprintf("%f", amount),
-			})
-		}
-		return balances
+func fetchJSON(url string, v interface{}) error {
+	body, err := fetchBody(url)
+	if err != nil {
+		return err
 	}
+	return json.Unmarshal(body, v)
 }
 
 func getHexAddress(address string) string {
@@ -7,14 +7,15 @@ import (
 	"testing"
 )
 
-func TestResolveSymbolForDenom(t *testing.T) {
+func TestResolveDenom(t *testing.T) {
 	// Create a mock HTTP server for assetlist.json
 	assetList := AssetList{
 		Assets: []Asset{
 			{
-				Base:    "uatom",
-				Display: "atom",
-				Symbol:  "ATOM",
+				Base:        "uatom",
+				Display:     "atom",
+				Symbol:      "ATOM",
+				CoingeckoID: "cosmos",
 				DenomUnits: []DenomUnit{
 					{Denom: "uatom", Exponent: 0},
 					{Denom: "atom", Exponent: 6},
@@ -44,16 +45,18 @@ func TestResolveSymbolForDenom(t *testing.T) {
 	defer func() { registryBaseURL = originalURL }()
 
 	tests := []struct {
-		name         string
-		denom        string
-		wantSymbol   string
-		wantDecimals int
+		name            string
+		denom           string
+		wantSymbol      string
+		wantDecimals    int
+		wantCoingeckoID string
 	}{
 		{
-			name:         "native token",
-			denom:        "uatom",
-			wantSymbol:   "ATOM",
-			wantDecimals: 6,
+			name:            "native token",
+			denom:           "uatom",
+			wantSymbol:      "ATOM",
+			wantDecimals:    6,
+			wantCoingeckoID: "cosmos",
 		},
 		{
 			name:         "ibc token",
@@ -71,12 +74,15 @@ func TestResolveSymbolForDenom(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			symbol, decimals := resolveSymbolForDenom("cosmoshub", tt.denom)
-			if symbol != tt.wantSymbol {
-				t.Errorf("resolveSymbolForDenom() symbol = %v, want %v", symbol, tt.wantSymbol)
+			info := resolveDenom("cosmoshub", tt.denom)
+			if info.symbol != tt.wantSymbol {
+				t.Errorf("resolveDenom() symbol = %v, want %v", info.symbol, tt.wantSymbol)
+			}
+			if info.decimals != tt.wantDecimals {
+				t.Errorf("resolveDenom() decimals = %v, want %v", info.decimals, tt.wantDecimals)
 			}
-			if decimals != tt.wantDecimals {
-				t.Errorf("resolveSymbolForDenom() decimals = %v, want %v", decimals, tt.wantDecimals)
+			if info.coingeckoID != tt.wantCoingeckoID {
+				t.Errorf("resolveDenom() coingeckoID = %v, want %v", info.coingeckoID, tt.wantCoingeckoID)
 			}
 		})
 	}
@@ -139,3 +145,102 @@ func TestGetActiveEndpoint(t *testing.T) {
 		})
 	}
 }
+
+func TestFailedQueryDropsCachedEndpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/down":
+			w.WriteHeader(http.StatusBadGateway)
+		case "/missing":
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	cached := func() bool {
+		cacheMutex.RLock()
+		defer cacheMutex.RUnlock()
+		_, ok := endpointCache["droptest"]
+		return ok
+	}
+	cacheMutex.Lock()
+	endpointCache["droptest"] = server.URL
+	cacheMutex.Unlock()
+	defer func() {
+		cacheMutex.Lock()
+		delete(endpointCache, "droptest")
+		cacheMutex.Unlock()
+	}()
+
+	if _, err := fetchBody(server.URL + "/missing"); err == nil {
+		t.Fatal("no error for a 404")
+	}
+	if !cached() {
+		t.Fatal("a 404 dropped the endpoint")
+	}
+	if _, err := fetchBody(server.URL + "/down"); err == nil {
+		t.Fatal("no error for a 502")
+	}
+	if cached() {
+		t.Error("the endpoint is still cached after a 502")
+	}
+}
+
+func TestResolveDenomViaDenomTrace(t *testing.T) {
+	var server *httptest.Server
+	traceFailures := 1
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/gaia/chain.json":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"chain_name": "gaia",
+				"apis":       map[string]interface{}{"rest": []RestEndpoint{{Address: server.URL}}},
+			})
+		case "/gaia/assetlist.json":
+			json.NewEncoder(w).Encode(AssetList{})
+		case "/osmo/assetlist.json":
+			json.NewEncoder(w).Encode(AssetList{Assets: []Asset{{
+				Base:        "uosmo",
+				Display:     "osmo",
+				Symbol:      "OSMO",
+				CoingeckoID: "osmosis",
+				DenomUnits:  []DenomUnit{{Denom: "uosmo", Exponent: 0}, {Denom: "osmo", Exponent: 6}},
+			}}})
+		case "/cosmos/base/tendermint/v1beta1/node_info":
+			w.Write([]byte(`{}`))
+		case "/ibc/apps/transfer/v1/denom_traces/ABC123":
+			if traceFailures > 0 {
+				traceFailures--
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.Write([]byte(`{"denom_trace":{"path":"transfer/channel-141","base_denom":"uosmo"}}`))
+		case "/ibc/core/channel/v1/channels/channel-141/ports/transfer/client_state":
+			w.Write([]byte(`{"identified_client_state":{"client_state":{"chain_id":"osmosis-1"}}}`))
+		case "/directory":
+			w.Write([]byte(`{"chains":[{"name":"osmo","chain_id":"osmosis-1"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	originalURL, originalDirectory := registryBaseURL, chainDirectoryURL
+	registryBaseURL, chainDirectoryURL = server.URL, server.URL+"/directory"
+	defer func() { registryBaseURL, chainDirectoryURL = originalURL, originalDirectory }()
+
+	// A failed query is not cached, so the next refresh resolves the denom.
+	if info := resolveDenom("gaia", "ibc/ABC123"); info.symbol != "ibc/ABC123" {
+		t.Errorf("resolveDenom() during an outage = %+v, want raw denom", info)
+	}
+	info := resolveDenom("gaia", "ibc/ABC123")
+	if info.symbol != "OSMO" || info.decimals != 6 || info.coingeckoID != "osmosis" {
+		t.Errorf("resolveDenom() = %+v, want OSMO with 6 decimals priced as osmosis", info)
+	}
+
+	info = resolveDenom("gaia", "ibc/UNKNOWN")
+	if info.symbol != "ibc/UNKNOWN" {
+		t.Errorf("resolveDenom() symbol = %v, want raw denom", info.symbol)
+	}
+}
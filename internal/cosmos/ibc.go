@@ -0,0 +1,139 @@
+package cosmos
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/anilcse/cosmoscope/internal/metrics"
+)
+
+// resolvedDenom is the cached outcome of resolving an IBC denom. A denom
+// that cannot be resolved is cached too so a missing trace is only queried
+// once; other failures are retried on the next refresh.
+type resolvedDenom struct {
+	info denomInfo
+	ok   bool
+}
+
+// errUnresolvable marks a denom trace that the registry data cannot
+// resolve, as opposed to a query that failed.
+var errUnresolvable = errors.New("unresolvable denom trace")
+
+// resolveIBCDenom resolves an ibc/HASH denom held on network by querying
+// its denom trace, walking every hop of the trace path back to the origin
+// chain, and looking up the base denom in the origin chain's asset list.
+func resolveIBCDenom(network, denom string) (denomInfo, error) {
+	key := network + "/" + denom
+
+	cacheMutex.RLock()
+	cached, exists := denomTraceCache[key]
+	cacheMutex.RUnlock()
+	if exists {
+		if !cached.ok {
+			return denomInfo{}, fmt.Errorf("denom trace for %s could not be resolved", denom)
+		}
+		return cached.info, nil
+	}
+
+	info, err := traceIBCDenom(network, denom)
+	if err == nil || isNotFound(err) || errors.Is(err, errUnresolvable) {
+		cacheMutex.Lock()
+		denomTraceCache[key] = resolvedDenom{info: info, ok: err == nil}
+		cacheMutex.Unlock()
+	}
+
+	return info, err
+}
+
+func traceIBCDenom(network, denom string) (denomInfo, error) {
+	api, err := activeEndpointFor(network)
+	if err != nil {
+		return denomInfo{}, err
+	}
+
+	var trace DenomTraceResponse
+	hash := strings.TrimPrefix(denom, "ibc/")
+	if err := fetchJSON(fmt.Sprintf("%s/ibc/apps/transfer/v1/denom_traces/%s", api, hash), &trace); err != nil {
+		metrics.RecordFailure(api, "denom_trace")
+		return denomInfo{}, fmt.Errorf("error fetching denom trace: %w", err)
+	}
+
+	hops := strings.Split(trace.DenomTrace.Path, "/")
+	if trace.DenomTrace.BaseDenom == "" || len(hops)%2 != 0 {
+		return denomInfo{}, fmt.Errorf("%w: invalid path %q for %s", errUnresolvable, trace.DenomTrace.Path, denom)
+	}
+
+	origin := network
+	for i := 0; i < len(hops); i += 2 {
+		origin, err = counterpartyChain(origin, hops[i], hops[i+1])
+		if err != nil {
+			return denomInfo{}, err
+		}
+	}
+
+	assetList, err := fetchAssetList(origin)
+	if err != nil {
+		return denomInfo{}, err
+	}
+	info, ok := findAsset(assetList, trace.DenomTrace.BaseDenom)
+	if !ok {
+		return denomInfo{}, fmt.Errorf("%w: %s not found in %s asset list", errUnresolvable, trace.DenomTrace.BaseDenom, origin)
+	}
+
+	return info, nil
+}
+
+// counterpartyChain returns the chain registry name of the chain at the
+// other end of port/channel on network.
+func counterpartyChain(network, port, channel string) (string, error) {
+	api, err := activeEndpointFor(network)
+	if err != nil {
+		return "", err
+	}
+
+	var response ChannelClientStateResponse
+	url := fmt.Sprintf("%s/ibc/core/channel/v1/channels/%s/ports/%s/client_state", api, channel, port)
+	if err := fetchJSON(url, &response); err != nil {
+		metrics.RecordFailure(api, "client_state")
+		return "", fmt.Errorf("error fetching client state for %s on %s: %w", channel, network, err)
+	}
+
+	chainID := response.IdentifiedClientState.ClientState.ChainID
+	if chainID == "" {
+		return "", fmt.Errorf("%w: no counterparty chain id for %s on %s", errUnresolvable, channel, network)
+	}
+
+	return chainNameForID(chainID)
+}
+
+// chainNameForID maps a chain id to its chain registry directory name.
+func chainNameForID(chainID string) (string, error) {
+	cacheMutex.RLock()
+	name, exists := chainNameCache[chainID]
+	loaded := len(chainNameCache) > 0
+	cacheMutex.RUnlock()
+	if exists {
+		return name, nil
+	}
+	if loaded {
+		return "", fmt.Errorf("%w: chain id %s is not in the chain directory", errUnresolvable, chainID)
+	}
+
+	var directory ChainDirectoryResponse
+	if err := fetchJSON(chainDirectoryURL, &directory); err != nil {
+		return "", fmt.Errorf("error fetching chain directory: %v", err)
+	}
+
+	cacheMutex.Lock()
+	for _, chain := range directory.Chains {
+		chainNameCache[chain.ChainID] = chain.Name
+	}
+	name, exists = chainNameCache[chainID]
+	cacheMutex.Unlock()
+
+	if !exists {
+		return "", fmt.Errorf("%w: chain id %s is not in the chain directory", errUnresolvable, chainID)
+	}
+	return name, nil
+}
@@ -0,0 +1,72 @@
+package cosmos
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/anilcse/cosmoscope/internal/config"
+)
+
+func TestAssetOverridePrecedence(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"assets":[{"base":"uatom","symbol":"ATOM","display":"atom","denom_units":[{"denom":"uatom","exponent":0},{"denom":"atom","exponent":6}]}]}`))
+	}))
+	defer server.Close()
+	originalURL := registryBaseURL
+	registryBaseURL = server.URL
+	defer func() { registryBaseURL = originalURL }()
+
+	SetAssetOverrides(map[string]*config.IBCAsset{
+		config.AssetKey("", "ufoo"):              {Denom: "ufoo", Symbol: "FOO", Decimals: 6},
+		config.AssetKey("overridechain", "ufoo"): {Denom: "ufoo", Symbol: "FOO.o", Decimals: 8, Network: "overridechain"},
+		config.AssetKey("", "uatom"):             {Denom: "uatom", Symbol: "MYATOM", Decimals: 3},
+	})
+	defer SetAssetOverrides(nil)
+
+	tests := []struct {
+		network, denom string
+		wantSymbol     string
+		wantDecimals   int
+	}{
+		{"overridechain", "ufoo", "FOO.o", 8},
+		{"otherchain", "ufoo", "FOO", 6},
+		{"otherchain", "uatom", "MYATOM", 3},
+	}
+	for _, tt := range tests {
+		info := resolveDenom(tt.network, tt.denom)
+		if info.symbol != tt.wantSymbol || info.decimals != tt.wantDecimals {
+			t.Errorf("resolveDenom(%s, %s) = %s/%d, want %s/%d", tt.network, tt.denom, info.symbol, info.decimals, tt.wantSymbol, tt.wantDecimals)
+		}
+	}
+}
+
+func TestUnresolvedDenoms(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"assets":[]}`))
+	}))
+	defer server.Close()
+	originalURL := registryBaseURL
+	registryBaseURL = server.URL
+	defer func() { registryBaseURL = originalURL }()
+
+	cacheMutex.Lock()
+	unresolvedDenoms = make(map[string]struct{})
+	cacheMutex.Unlock()
+
+	SetAssetOverrides(map[string]*config.IBCAsset{
+		config.AssetKey("", "uknown"): {Denom: "uknown", Symbol: "KNOWN", Decimals: 6},
+	})
+	defer SetAssetOverrides(nil)
+
+	resolveDenom("unresolvedchain", "uknown")
+	resolveDenom("unresolvedchain", "umystery")
+	resolveDenom("unresolvedchain", "umystery")
+	resolveDenom("unresolvedchain", "factory/osmo1abc/coin")
+
+	want := []string{"unresolvedchain:factory/osmo1abc/coin", "unresolvedchain:umystery"}
+	if got := UnresolvedDenoms(); !reflect.DeepEqual(got, want) {
+		t.Errorf("UnresolvedDenoms() = %v, want %v", got, want)
+	}
+}
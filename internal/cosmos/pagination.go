@@ -0,0 +1,127 @@
+package cosmos
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/anilcse/cosmoscope/internal/metrics"
+)
+
+// maxPages guards against endpoints that keep returning the same next_key.
+const maxPages = 1000
+
+type PageResponse struct {
+	NextKey string `json:"next_key"`
+	Total   string `json:"total"`
+}
+
+// fetchAllPages requests rawURL and keeps following pagination.next_key
+// until the last page, handing the body of every page to handle.
+func fetchAllPages(rawURL string, handle func(body []byte) error) error {
+	nextKey := ""
+	for page := 0; page < maxPages; page++ {
+		pageURL, err := withPageKey(rawURL, nextKey)
+		if err != nil {
+			return err
+		}
+
+		body, err := fetchBody(pageURL)
+		if err != nil {
+			return err
+		}
+		if err := handle(body); err != nil {
+			return err
+		}
+
+		var response struct {
+			Pagination *PageResponse `json:"pagination"`
+		}
+		if err := json.Unmarshal(body, &response); err != nil {
+			return err
+		}
+		if response.Pagination == nil || response.Pagination.NextKey == "" {
+			return nil
+		}
+		if response.Pagination.NextKey == nextKey {
+			return fmt.Errorf("pagination key did not advance for %s", rawURL)
+		}
+		nextKey = response.Pagination.NextKey
+	}
+
+	return fmt.Errorf("more than %d pages returned by %s", maxPages, rawURL)
+}
+
+func withPageKey(rawURL, key string) (string, error) {
+	if key == "" {
+		return rawURL, nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	query.Set("pagination.key", key)
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+func fetchBody(url string) ([]byte, error) {
+	client := &http.Client{Timeout: time.Second * 10}
+	start := time.Now()
+	resp, err := client.Get(url)
+	metrics.ObserveRequest(url, time.Since(start))
+	if err != nil {
+		dropEndpoint(url)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		err := &statusError{status: resp.StatusCode, body: body}
+		// An endpoint that is down or throttling is replaced on the next
+		// query; other statuses are about the request, not the endpoint.
+		if (resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests) && !isNotFound(err) {
+			dropEndpoint(url)
+		}
+		return nil, err
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// grpcNotFound is the gRPC NotFound code the REST gateway puts in error
+// bodies.
+const grpcNotFound = 5
+
+// statusError is a non-200 response from a REST endpoint.
+type statusError struct {
+	status int
+	body   []byte
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected status %d", e.status)
+}
+
+// isNotFound reports whether err says the queried object does not exist,
+// either as a 404 or as a gateway error carrying the NotFound code.
+func isNotFound(err error) bool {
+	var statusErr *statusError
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+	if statusErr.status == http.StatusNotFound {
+		return true
+	}
+	var response struct {
+		Code int `json:"code"`
+	}
+	return json.Unmarshal(statusErr.body, &response) == nil && response.Code == grpcNotFound
+}
@@ -0,0 +1,79 @@
+package cosmos
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/anilcse/cosmoscope/internal/portfolio"
+)
+
+func TestGetBalanceFollowsPagination(t *testing.T) {
+	pages := map[string]string{
+		"":         `{"balances":[{"denom":"uatom","amount":"1"}],"pagination":{"next_key":"cGFnZTI=","total":"3"}}`,
+		"cGFnZTI=": `{"balances":[{"denom":"uosmo","amount":"2"}],"pagination":{"next_key":"cGFnZTM=","total":"3"}}`,
+		"cGFnZTM=": `{"balances":[{"denom":"ustars","amount":"3"}],"pagination":{"next_key":null,"total":"3"}}`,
+	}
+
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		page, ok := pages[r.URL.Query().Get("pagination.key")]
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, page)
+	}))
+	defer server.Close()
+
+	balances := getBalance(server.URL, "cosmos1test", "/cosmos/bank/v1beta1/balances")
+	if requests != 3 {
+		t.Errorf("requests = %d, want 3", requests)
+	}
+	if len(balances) != 3 {
+		t.Fatalf("len(balances) = %d, want 3", len(balances))
+	}
+	for i, denom := range []string{"uatom", "uosmo", "ustars"} {
+		if balances[i].Denom != denom {
+			t.Errorf("balances[%d].Denom = %v, want %v", i, balances[i].Denom, denom)
+		}
+	}
+}
+
+func TestQueryStakingBalancesFollowsPagination(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/paginated/assetlist.json" {
+			fmt.Fprint(w, `{"assets":[]}`)
+			return
+		}
+
+		validator := "cosmosvaloper1a"
+		nextKey := `"bmV4dA=="`
+		if r.URL.Query().Get("pagination.key") == "bmV4dA==" {
+			validator, nextKey = "cosmosvaloper1b", `""`
+		}
+		fmt.Fprintf(w, `{"delegation_responses":[{"delegation":{"validator_address":%q},"balance":{"denom":"unknown","amount":"1000000"}}],"pagination":{"next_key":%s}}`, validator, nextKey)
+	}))
+	defer server.Close()
+
+	originalURL := registryBaseURL
+	registryBaseURL = server.URL
+	defer func() { registryBaseURL = originalURL }()
+
+	balanceChan := make(chan portfolio.Balance, 10)
+	queryStakingBalances("paginated", server.URL, "cosmos1test", nil, balanceChan)
+	close(balanceChan)
+
+	var count int
+	for balance := range balanceChan {
+		count++
+		if balance.Network != "paginated-staking" {
+			t.Errorf("Network = %v, want paginated-staking", balance.Network)
+		}
+	}
+	if count != 2 {
+		t.Errorf("delegations = %d, want 2", count)
+	}
+}
@@ -0,0 +1,266 @@
+package cosmos
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anilcse/cosmoscope/internal/metrics"
+	"github.com/anilcse/cosmoscope/internal/portfolio"
+	"github.com/anilcse/cosmoscope/pkg/utils"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+)
+
+// stakingServer serves an account that has unbonded or redelegated part of
+// its stake and holds nothing in its bank balance.
+func stakingServer(t *testing.T, network, address string) *httptest.Server {
+	t.Helper()
+	responses := map[string]string{
+		"/" + network + "/assetlist.json":          `{"assets":[{"base":"uatom","symbol":"ATOM","coingecko_id":"cosmos","denom_units":[{"denom":"uatom","exponent":0},{"denom":"atom","exponent":6}],"display":"atom"}]}`,
+		"/cosmos/staking/v1beta1/params":           `{"params":{"bond_denom":"uatom"}}`,
+		"/cosmos/auth/v1beta1/accounts/" + address: `{"account":{"@type":"/cosmos.auth.v1beta1.BaseAccount"}}`,
+		"/cosmos/bank/v1beta1/balances/" + address: `{"balances":[],"pagination":{"next_key":null}}`,
+		"/cosmos/staking/v1beta1/delegations/" + address: `{"delegation_responses":[
+			{"delegation":{"validator_address":"cosmosvaloper1a"},"balance":{"denom":"uatom","amount":"5000000"}},
+			{"delegation":{"validator_address":"cosmosvaloper1b"},"balance":{"denom":"uatom","amount":"2000000"}}
+		],"pagination":{"next_key":null}}`,
+		"/cosmos/staking/v1beta1/delegators/" + address + "/unbonding_delegations": `{"unbonding_responses":[
+			{"validator_address":"cosmosvaloper1a","entries":[
+				{"completion_time":"2030-01-02T00:00:00Z","balance":"1500000"},
+				{"completion_time":"2030-01-01T00:00:00Z","balance":"500000"}
+			]}
+		],"pagination":{"next_key":null}}`,
+		"/cosmos/staking/v1beta1/delegators/" + address + "/redelegations": `{"redelegation_responses":[
+			{"redelegation":{"validator_src_address":"cosmosvaloper1a","validator_dst_address":"cosmosvaloper1b"},"entries":[
+				{"redelegation_entry":{"completion_time":"2030-01-03T00:00:00Z"},"balance":"2000000"}
+			]}
+		],"pagination":{"next_key":null}}`,
+		"/cosmos/distribution/v1beta1/delegators/" + address + "/rewards": `{"rewards":[],"total":[]}`,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+
+	originalURL := registryBaseURL
+	registryBaseURL = server.URL
+	cacheMutex.Lock()
+	endpointCache[network] = server.URL
+	cacheMutex.Unlock()
+	t.Cleanup(func() {
+		server.Close()
+		registryBaseURL = originalURL
+		cacheMutex.Lock()
+		delete(endpointCache, network)
+		cacheMutex.Unlock()
+	})
+	return server
+}
+
+func collectBalances(query func(chan<- portfolio.Balance)) []portfolio.Balance {
+	balanceChan := make(chan portfolio.Balance, 100)
+	query(balanceChan)
+	close(balanceChan)
+
+	var balances []portfolio.Balance
+	for b := range balanceChan {
+		balances = append(balances, b)
+	}
+	sort.SliceStable(balances, func(i, j int) bool { return balances[i].Network < balances[j].Network })
+	return balances
+}
+
+func TestQueryUnbondingDelegations(t *testing.T) {
+	server := stakingServer(t, "unbondtest", "cosmos1test")
+
+	balances := collectBalances(func(ch chan<- portfolio.Balance) {
+		queryUnbondingDelegations("unbondtest", server.URL, "cosmos1test", ch)
+	})
+	if len(balances) != 2 {
+		t.Fatalf("got %d entries, want 2: %+v", len(balances), balances)
+	}
+	want := []struct {
+		amount string
+		time   time.Time
+	}{
+		{"1.5", time.Date(2030, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"0.5", time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for i, b := range balances {
+		if b.Network != "unbondtest-unbonding" || b.Token != "ATOM" || b.Amount.String() != want[i].amount || b.CompletionTime == nil || !b.CompletionTime.Equal(want[i].time) {
+			t.Errorf("entry %d = %s %s %s %v", i, b.Network, b.Token, b.Amount, b.CompletionTime)
+		}
+	}
+}
+
+func TestQueryRedelegations(t *testing.T) {
+	server := stakingServer(t, "redelegatetest", "cosmos1test")
+
+	var redelegating map[string]utils.Amount
+	balances := collectBalances(func(ch chan<- portfolio.Balance) {
+		redelegating = queryRedelegations("redelegatetest", server.URL, "cosmos1test", ch)
+	})
+	if len(balances) != 1 || balances[0].Network != "redelegatetest-redelegating" || balances[0].Amount.String() != "2" {
+		t.Fatalf("balances = %+v", balances)
+	}
+	if got := redelegating["cosmosvaloper1b"].String(); got != "2000000" {
+		t.Errorf("redelegating to cosmosvaloper1b = %s base units, want 2000000", got)
+	}
+}
+
+func TestQueryBalancesWithEmptyBank(t *testing.T) {
+	stakingServer(t, "emptybank", "cosmos1test")
+
+	balances := collectBalances(func(ch chan<- portfolio.Balance) {
+		QueryBalances("emptybank", "cosmos1test", ch)
+	})
+
+	amounts := make(map[string]string)
+	for _, b := range balances {
+		amounts[b.Network] = amounts[b.Network] + b.Amount.String() + " "
+	}
+	want := map[string]string{
+		// The 2 ATOM redelegated to cosmosvaloper1b are reported as
+		// redelegating, not again as staked.
+		"emptybank-staking":      "5 ",
+		"emptybank-unbonding":    "1.5 0.5 ",
+		"emptybank-redelegating": "2 ",
+	}
+	for network, amount := range want {
+		if amounts[network] != amount {
+			t.Errorf("%s = %q, want %q", network, amounts[network], amount)
+		}
+	}
+	if len(amounts) != len(want) {
+		t.Errorf("unexpected balances: %v", amounts)
+	}
+}
+
+func TestQueryBalancesWithoutAccount(t *testing.T) {
+	server := stakingServer(t, "noaccount", "cosmos1test")
+	mux := http.NewServeMux()
+	mux.HandleFunc("/cosmos/auth/v1beta1/accounts/cosmos1test", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"code":5,"message":"account cosmos1test not found"}`)
+	})
+	mux.Handle("/", server.Config.Handler)
+	server.Config.Handler = mux
+
+	failures := metrics.Failures()
+	balances := collectBalances(func(ch chan<- portfolio.Balance) {
+		QueryBalances("noaccount", "cosmos1test", ch)
+	})
+	if got := metrics.Failures() - failures; got != 0 {
+		t.Errorf("recorded %d failures for a missing account, want 0", got)
+	}
+	if len(balances) == 0 {
+		t.Error("missing account stopped the balance queries")
+	}
+}
+
+func TestQueryCommission(t *testing.T) {
+	server := stakingServer(t, "commissiontest", "cosmos1test")
+	mux := http.NewServeMux()
+	mux.HandleFunc("/cosmos/distribution/v1beta1/validators/cosmosvaloper1a/commission", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"commission":{"commission":[{"denom":"uatom","amount":"1234567.891"}]}}`)
+	})
+	mux.HandleFunc("/cosmos/distribution/v1beta1/validators/cosmosvaloper1b/commission", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"code":5,"message":"validator does not exist"}`)
+	})
+	mux.Handle("/", server.Config.Handler)
+	server.Config.Handler = mux
+
+	balances := collectBalances(func(ch chan<- portfolio.Balance) {
+		QueryCommission("commissiontest", "cosmosvaloper1a", ch)
+		QueryCommission("commissiontest", "cosmosvaloper1b", ch)
+	})
+	if len(balances) != 1 {
+		t.Fatalf("got %d balances, want 1: %+v", len(balances), balances)
+	}
+	if b := balances[0]; b.Network != "commissiontest-commission" || b.Account != "cosmosvaloper1a" || b.Token != "ATOM" || b.Amount.String() != "1.234567891" {
+		t.Errorf("commission = %s %s %s %s", b.Network, b.Account, b.Token, b.Amount)
+	}
+}
+
+func TestIsNotFound(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&statusError{status: http.StatusNotFound}, true},
+		{&statusError{status: http.StatusInternalServerError, body: []byte(`{"code":5,"message":"not found"}`)}, true},
+		{&statusError{status: http.StatusInternalServerError, body: []byte(`{"code":2,"message":"internal"}`)}, false},
+		{fmt.Errorf("connection refused"), false},
+	}
+	for _, tt := range tests {
+		if got := isNotFound(tt.err); got != tt.want {
+			t.Errorf("isNotFound(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestIsSelfDelegation(t *testing.T) {
+	encode := func(prefix string, key byte) string {
+		address, err := bech32.ConvertAndEncode(prefix, bytes.Repeat([]byte{key}, 20))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return address
+	}
+	delegator := encode("cosmos", 1)
+
+	if own := encode("cosmosvaloper", 1); !isSelfDelegation(delegator, own) {
+		t.Errorf("delegation from %s to %s is not a self-delegation", delegator, own)
+	}
+	if other := encode("cosmosvaloper", 2); isSelfDelegation(delegator, other) {
+		t.Errorf("delegation from %s to %s is a self-delegation", delegator, other)
+	}
+	if isSelfDelegation("invalid", "invalid") {
+		t.Error("invalid addresses match")
+	}
+}
+
+func TestFailedQueriesAreCounted(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	collectBalances(func(ch chan<- portfolio.Balance) {
+		queryStakingBalances("failtest", server.URL, "cosmos1test", nil, ch)
+		queryUnbondingDelegations("failtest", server.URL, "cosmos1test", ch)
+		queryRedelegations("failtest", server.URL, "cosmos1test", ch)
+	})
+
+	scrape := httptest.NewServer(metrics.Handler())
+	defer scrape.Close()
+	resp, err := scrape.Client().Get(scrape.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	for _, query := range []string{"staking", "unbonding", "redelegations"} {
+		want := fmt.Sprintf(`cosmoscope_endpoint_request_failures_total{endpoint=%q,query=%q} 1`, host, query)
+		if !strings.Contains(string(body), want) {
+			t.Errorf("metrics output missing %s", want)
+		}
+	}
+}
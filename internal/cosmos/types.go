@@ -1,5 +1,7 @@
 package cosmos
 
+import "time"
+
 type ChainInfo struct {
 	ChainName    string `json:"chain_name"`
 	Bech32Prefix string `json:"bech32_prefix"`
@@ -25,6 +27,7 @@ type Asset struct {
 	Name        string      `json:"name"`
 	Symbol      string      `json:"symbol"`
 	TypeAsset   string      `json:"type_asset"`
+	CoingeckoID string      `json:"coingecko_id"`
 }
 
 type DenomUnit struct {
@@ -65,3 +68,100 @@ type RewardsResponse struct {
 		} `json:"reward"`
 	} `json:"rewards"`
 }
+
+type StakingParamsResponse struct {
+	Params struct {
+		BondDenom string `json:"bond_denom"`
+	} `json:"params"`
+}
+
+type UnbondingDelegationsResponse struct {
+	UnbondingResponses []struct {
+		DelegatorAddress string           `json:"delegator_address"`
+		ValidatorAddress string           `json:"validator_address"`
+		Entries          []UnbondingEntry `json:"entries"`
+	} `json:"unbonding_responses"`
+}
+
+type UnbondingEntry struct {
+	CreationHeight string    `json:"creation_height"`
+	CompletionTime time.Time `json:"completion_time"`
+	InitialBalance string    `json:"initial_balance"`
+	Balance        string    `json:"balance"`
+}
+
+type RedelegationsResponse struct {
+	RedelegationResponses []struct {
+		Redelegation struct {
+			DelegatorAddress    string `json:"delegator_address"`
+			ValidatorSrcAddress string `json:"validator_src_address"`
+			ValidatorDstAddress string `json:"validator_dst_address"`
+		} `json:"redelegation"`
+		Entries []struct {
+			RedelegationEntry struct {
+				CreationHeight string    `json:"creation_height"`
+				CompletionTime time.Time `json:"completion_time"`
+				InitialBalance string    `json:"initial_balance"`
+				SharesDst      string    `json:"shares_dst"`
+			} `json:"redelegation_entry"`
+			Balance string `json:"balance"`
+		} `json:"entries"`
+	} `json:"redelegation_responses"`
+}
+
+type Coin struct {
+	Denom  string `json:"denom"`
+	Amount string `json:"amount"`
+}
+
+type AccountResponse struct {
+	Account VestingAccount `json:"account"`
+}
+
+// VestingAccount covers the fields shared by the x/auth/vesting account
+// types. Non-vesting accounts decode with an empty BaseVestingAccount.
+type VestingAccount struct {
+	Type               string `json:"@type"`
+	BaseVestingAccount struct {
+		OriginalVesting  []Coin `json:"original_vesting"`
+		DelegatedFree    []Coin `json:"delegated_free"`
+		DelegatedVesting []Coin `json:"delegated_vesting"`
+		EndTime          string `json:"end_time"`
+	} `json:"base_vesting_account"`
+	StartTime      string          `json:"start_time"`
+	VestingPeriods []VestingPeriod `json:"vesting_periods"`
+}
+
+type VestingPeriod struct {
+	Length string `json:"length"`
+	Amount []Coin `json:"amount"`
+}
+
+type ValidatorCommissionResponse struct {
+	Commission struct {
+		Commission []Coin `json:"commission"`
+	} `json:"commission"`
+}
+
+type DenomTraceResponse struct {
+	DenomTrace struct {
+		Path      string `json:"path"`
+		BaseDenom string `json:"base_denom"`
+	} `json:"denom_trace"`
+}
+
+type ChannelClientStateResponse struct {
+	IdentifiedClientState struct {
+		ClientID    string `json:"client_id"`
+		ClientState struct {
+			ChainID string `json:"chain_id"`
+		} `json:"client_state"`
+	} `json:"identified_client_state"`
+}
+
+type ChainDirectoryResponse struct {
+	Chains []struct {
+		Name    string `json:"name"`
+		ChainID string `json:"chain_id"`
+	} `json:"chains"`
+}
@@ -0,0 +1,246 @@
+package cosmos
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+)
+
+const (
+	continuousVestingAccount = "/cosmos.vesting.v1beta1.ContinuousVestingAccount"
+	delayedVestingAccount    = "/cosmos.vesting.v1beta1.DelayedVestingAccount"
+	periodicVestingAccount   = "/cosmos.vesting.v1beta1.PeriodicVestingAccount"
+	permanentLockedAccount   = "/cosmos.vesting.v1beta1.PermanentLockedAccount"
+
+	// maxContinuousUnlocks caps how many tranches a linear schedule is
+	// projected into.
+	maxContinuousUnlocks = 24
+)
+
+// vestingState is the per-denom view of a vesting account at a point in time.
+// Amounts are in base units.
+type vestingState struct {
+	// locked is the part of the bank balance that cannot be spent yet,
+	// before it is capped at the bank balance.
+	locked map[string]*big.Int
+	// vesting is the still-vesting amount, delegated tokens included.
+	vesting map[string]*big.Int
+	// delegated is the vesting amount that is delegated.
+	delegated map[string]*big.Int
+	// unlocks is the projected schedule of the still-vesting amount.
+	unlocks map[string][]vestingUnlock
+}
+
+type vestingUnlock struct {
+	time   time.Time
+	amount *big.Int
+}
+
+// queryVesting returns the vesting state of address, or nil when the
+// account is not a vesting account.
+func queryVesting(api, address string, now time.Time) (*vestingState, error) {
+	var response AccountResponse
+	url := fmt.Sprintf("%s/cosmos/auth/v1beta1/accounts/%s", api, address)
+	if err := fetchJSON(url, &response); err != nil {
+		return nil, err
+	}
+	return computeVesting(response.Account, now), nil
+}
+
+func computeVesting(account VestingAccount, now time.Time) *vestingState {
+	switch account.Type {
+	case continuousVestingAccount, delayedVestingAccount, periodicVestingAccount, permanentLockedAccount:
+	default:
+		return nil
+	}
+
+	base := account.BaseVestingAccount
+	start := parseUnixTime(account.StartTime)
+	end := parseUnixTime(base.EndTime)
+	delegatedVesting := coinsToMap(base.DelegatedVesting)
+
+	state := &vestingState{
+		locked:    make(map[string]*big.Int),
+		vesting:   make(map[string]*big.Int),
+		delegated: delegatedVesting,
+		unlocks:   make(map[string][]vestingUnlock),
+	}
+
+	for _, coin := range base.OriginalVesting {
+		original, ok := new(big.Int).SetString(coin.Amount, 10)
+		if !ok {
+			continue
+		}
+
+		var stillVesting *big.Int
+		var unlocks []vestingUnlock
+		switch account.Type {
+		case continuousVestingAccount:
+			stillVesting, unlocks = continuousSchedule(original, start, end, now)
+		case delayedVestingAccount:
+			stillVesting = new(big.Int)
+			if now.Before(end) {
+				stillVesting.Set(original)
+				unlocks = []vestingUnlock{{time: end, amount: original}}
+			}
+		case periodicVestingAccount:
+			stillVesting, unlocks = periodicSchedule(account.VestingPeriods, coin.Denom, start, now)
+		case permanentLockedAccount:
+			stillVesting = original
+		}
+
+		// Vesting tokens that are delegated are not in the bank balance, so
+		// only the remainder locks bank funds.
+		locked := new(big.Int).Set(stillVesting)
+		if delegated, ok := delegatedVesting[coin.Denom]; ok {
+			locked.Sub(locked, delegated)
+		}
+		if locked.Sign() < 0 {
+			locked.SetInt64(0)
+		}
+
+		state.locked[coin.Denom] = locked
+		state.vesting[coin.Denom] = stillVesting
+		state.unlocks[coin.Denom] = unlocks
+	}
+
+	return state
+}
+
+// bankUnlocks returns how much of a bank balance of denom is locked and
+// when that part unlocks. Delegated vesting tokens are not in the bank
+// balance, so the first tokens to vest free the bank balance, and a bank
+// balance smaller than the locked amount is only freed once the
+// still-vesting amount drops below it.
+func (v *vestingState) bankUnlocks(denom string, bank *big.Int) (*big.Int, []vestingUnlock) {
+	lockedWhile := func(vesting *big.Int) *big.Int {
+		locked := new(big.Int).Set(vesting)
+		if delegated, ok := v.delegated[denom]; ok {
+			locked.Sub(locked, delegated)
+		}
+		if locked.Sign() < 0 {
+			locked.SetInt64(0)
+		}
+		if locked.Cmp(bank) > 0 {
+			locked.Set(bank)
+		}
+		return locked
+	}
+
+	vesting, ok := v.vesting[denom]
+	if !ok {
+		return new(big.Int), nil
+	}
+	locked := lockedWhile(vesting)
+
+	var unlocks []vestingUnlock
+	remaining := new(big.Int).Set(vesting)
+	previous := locked
+	for _, unlock := range v.unlocks[denom] {
+		remaining.Sub(remaining, unlock.amount)
+		current := lockedWhile(remaining)
+		if freed := new(big.Int).Sub(previous, current); freed.Sign() > 0 {
+			unlocks = append(unlocks, vestingUnlock{time: unlock.time, amount: freed})
+		}
+		previous = current
+	}
+	return locked, unlocks
+}
+
+func continuousSchedule(original *big.Int, start, end, now time.Time) (*big.Int, []vestingUnlock) {
+	vestedAt := func(t time.Time) *big.Int {
+		switch {
+		case !t.After(start):
+			return new(big.Int)
+		case !t.Before(end):
+			return new(big.Int).Set(original)
+		}
+		elapsed := big.NewInt(int64(t.Sub(start).Seconds()))
+		total := big.NewInt(int64(end.Sub(start).Seconds()))
+		vested := new(big.Int).Mul(original, elapsed)
+		return vested.Quo(vested, total)
+	}
+
+	stillVesting := new(big.Int).Sub(original, vestedAt(now))
+	if stillVesting.Sign() <= 0 {
+		return new(big.Int), nil
+	}
+
+	from := now
+	if from.Before(start) {
+		from = start
+	}
+	step := 30 * 24 * time.Hour
+	if remaining := end.Sub(from); remaining > step*maxContinuousUnlocks {
+		step = remaining / maxContinuousUnlocks
+	}
+
+	var unlocks []vestingUnlock
+	previous := vestedAt(now)
+	for t := from.Add(step); ; t = t.Add(step) {
+		if t.After(end) {
+			t = end
+		}
+		vested := vestedAt(t)
+		if amount := new(big.Int).Sub(vested, previous); amount.Sign() > 0 {
+			unlocks = append(unlocks, vestingUnlock{time: t, amount: amount})
+		}
+		previous = vested
+		if !t.Before(end) {
+			break
+		}
+	}
+
+	return stillVesting, unlocks
+}
+
+func periodicSchedule(periods []VestingPeriod, denom string, start, now time.Time) (*big.Int, []vestingUnlock) {
+	stillVesting := new(big.Int)
+	var unlocks []vestingUnlock
+
+	t := start
+	for _, period := range periods {
+		seconds, err := strconv.ParseInt(period.Length, 10, 64)
+		if err != nil {
+			continue
+		}
+		t = t.Add(time.Duration(seconds) * time.Second)
+		if !t.After(now) {
+			continue
+		}
+
+		amount, ok := coinsToMap(period.Amount)[denom]
+		if !ok || amount.Sign() == 0 {
+			continue
+		}
+		stillVesting.Add(stillVesting, amount)
+		unlocks = append(unlocks, vestingUnlock{time: t, amount: amount})
+	}
+
+	return stillVesting, unlocks
+}
+
+func coinsToMap(coins []Coin) map[string]*big.Int {
+	m := make(map[string]*big.Int)
+	for _, coin := range coins {
+		amount, ok := new(big.Int).SetString(coin.Amount, 10)
+		if !ok {
+			continue
+		}
+		if existing, ok := m[coin.Denom]; ok {
+			existing.Add(existing, amount)
+			continue
+		}
+		m[coin.Denom] = amount
+	}
+	return m
+}
+
+func parseUnixTime(s string) time.Time {
+	seconds, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0)
+}
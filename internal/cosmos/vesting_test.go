@@ -0,0 +1,105 @@
+package cosmos
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestComputeVesting(t *testing.T) {
+	now := time.Unix(100*86400, 0)
+
+	continuous := VestingAccount{Type: continuousVestingAccount, StartTime: "0"}
+	continuous.BaseVestingAccount.EndTime = "17280000"
+	continuous.BaseVestingAccount.OriginalVesting = []Coin{{Denom: "uatom", Amount: "1000"}}
+	continuous.BaseVestingAccount.DelegatedVesting = []Coin{{Denom: "uatom", Amount: "100"}}
+
+	periodic := VestingAccount{Type: periodicVestingAccount, StartTime: "0"}
+	periodic.BaseVestingAccount.OriginalVesting = []Coin{{Denom: "uatom", Amount: "300"}}
+	periodic.VestingPeriods = []VestingPeriod{
+		{Length: "5000000", Amount: []Coin{{Denom: "uatom", Amount: "100"}}},
+		{Length: "5000000", Amount: []Coin{{Denom: "uatom", Amount: "100"}}},
+		{Length: "5000000", Amount: []Coin{{Denom: "uatom", Amount: "100"}}},
+	}
+
+	tests := []struct {
+		name        string
+		account     VestingAccount
+		wantLocked  string
+		wantUnlocks int
+	}{
+		{
+			name:       "continuous vesting halfway with delegated vesting",
+			account:    continuous,
+			wantLocked: "400",
+			// The remaining 100 days are projected in thirty-day steps.
+			wantUnlocks: 4,
+		},
+		{
+			name:        "periodic vesting after first period",
+			account:     periodic,
+			wantLocked:  "200",
+			wantUnlocks: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := computeVesting(tt.account, now)
+			if state == nil {
+				t.Fatalf("computeVesting() = nil, want vesting state")
+			}
+			if got := state.locked["uatom"].String(); got != tt.wantLocked {
+				t.Errorf("locked = %v, want %v", got, tt.wantLocked)
+			}
+			if got := len(state.unlocks["uatom"]); got != tt.wantUnlocks {
+				t.Errorf("unlocks = %v, want %v", got, tt.wantUnlocks)
+			}
+		})
+	}
+
+	if state := computeVesting(VestingAccount{Type: "/cosmos.auth.v1beta1.BaseAccount"}, now); state != nil {
+		t.Errorf("computeVesting() for base account = %v, want nil", state)
+	}
+}
+
+func TestBankUnlocksWithDelegatedVesting(t *testing.T) {
+	continuous := VestingAccount{Type: continuousVestingAccount, StartTime: "0"}
+	continuous.BaseVestingAccount.EndTime = "17280000"
+	continuous.BaseVestingAccount.OriginalVesting = []Coin{{Denom: "uatom", Amount: "1000"}}
+	continuous.BaseVestingAccount.DelegatedVesting = []Coin{{Denom: "uatom", Amount: "100"}}
+	state := computeVesting(continuous, time.Unix(100*86400, 0))
+
+	tests := []struct {
+		name       string
+		bank       int64
+		wantLocked string
+		want       []string
+	}{
+		// 500 still vest in tranches of 150, 150, 150 and 50; the last 100
+		// to vest are the delegated ones.
+		{"bank covers locked", 1000, "400", []string{"150", "150", "100"}},
+		{"bank below locked", 300, "300", []string{"50", "150", "100"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			locked, unlocks := state.bankUnlocks("uatom", big.NewInt(tt.bank))
+			if locked.String() != tt.wantLocked {
+				t.Errorf("locked = %s, want %s", locked, tt.wantLocked)
+			}
+			var got []string
+			total := new(big.Int)
+			for _, unlock := range unlocks {
+				got = append(got, unlock.amount.String())
+				total.Add(total, unlock.amount)
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("unlocks = %v, want %v", got, tt.want)
+			}
+			if total.Cmp(locked) != 0 {
+				t.Errorf("unlocks add up to %s, want the locked %s", total, locked)
+			}
+		})
+	}
+}
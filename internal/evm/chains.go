@@ -0,0 +1,75 @@
+package evm
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/anilcse/cosmoscope/internal/config"
+)
+
+// moralisChains are the chains the Moralis EVM API indexes, by chain ID.
+// Chains without a well-known Moralis name use the hex chain ID, which
+// Moralis accepts for every chain it supports.
+var moralisChains = map[int]string{
+	1:        "eth",
+	11155111: "sepolia",
+	17000:    "holesky",
+	137:      "polygon",
+	80002:    "0x13882", // Polygon Amoy
+	56:       "bsc",
+	97:       "0x61", // BSC testnet
+	43114:    "avalanche",
+	250:      "fantom",
+	25:       "cronos",
+	42161:    "arbitrum",
+	421614:   "0x66eee", // Arbitrum Sepolia
+	100:      "gnosis",
+	10200:    "0x27d8", // Gnosis Chiado
+	8453:     "base",
+	84532:    "0x14a34", // Base Sepolia
+	10:       "optimism",
+	11155420: "0xaa37dc", // Optimism Sepolia
+	59144:    "linea",
+	59141:    "0xe705", // Linea Sepolia
+	1284:     "moonbeam",
+	1285:     "moonriver",
+	1287:     "0x507",  // Moonbase Alpha
+	747:      "0x2eb",  // Flow EVM
+	545:      "0x221",  // Flow EVM testnet
+	2020:     "0x7e4",  // Ronin
+	2021:     "0x7e5",  // Ronin Saigon
+	1135:     "0x46f",  // Lisk
+	4202:     "0x106a", // Lisk Sepolia
+	369:      "0x171",  // PulseChain
+}
+
+var (
+	unsupportedMutex sync.Mutex
+	// unsupportedWarned holds the networks already reported as not indexed,
+	// so each is warned about once rather than for every address.
+	unsupportedWarned = make(map[string]bool)
+)
+
+// IndexerChain returns the Moralis chain identifier of network: its
+// indexer_chain when set, otherwise the built-in name for its chain ID.
+// It reports false for chains Moralis does not index.
+func IndexerChain(network config.EVMNetwork) (string, bool) {
+	if network.IndexerChain != "" {
+		return network.IndexerChain, true
+	}
+	name, ok := moralisChains[network.ChainID]
+	return name, ok
+}
+
+func warnUnsupportedChain(network config.EVMNetwork) {
+	unsupportedMutex.Lock()
+	defer unsupportedMutex.Unlock()
+
+	if unsupportedWarned[network.Name] {
+		return
+	}
+	unsupportedWarned[network.Name] = true
+	fmt.Fprintf(os.Stderr, "Warning: %s (chain %d) is unsupported by the Moralis indexer; set indexer_chain or list its tokens to query ERC-20 balances\n",
+		network.Name, network.ChainID)
+}
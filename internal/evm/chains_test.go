@@ -0,0 +1,27 @@
+package evm
+
+import (
+	"testing"
+
+	"github.com/anilcse/cosmoscope/internal/config"
+)
+
+func TestIndexerChain(t *testing.T) {
+	tests := []struct {
+		network config.EVMNetwork
+		want    string
+		ok      bool
+	}{
+		{config.EVMNetwork{ChainID: 1}, "eth", true},
+		{config.EVMNetwork{ChainID: 8453}, "base", true},
+		{config.EVMNetwork{ChainID: 59141}, "0xe705", true},
+		{config.EVMNetwork{ChainID: 7777777}, "", false},
+		{config.EVMNetwork{ChainID: 7777777, IndexerChain: "zora"}, "zora", true},
+	}
+	for _, tt := range tests {
+		got, ok := IndexerChain(tt.network)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("IndexerChain(%d, %q) = %q, %v, want %q, %v", tt.network.ChainID, tt.network.IndexerChain, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -2,15 +2,14 @@ package evm
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"net/http"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/anilcse/cosmoscope/internal/config"
+	"github.com/anilcse/cosmoscope/internal/metrics"
 	"github.com/anilcse/cosmoscope/internal/portfolio"
-	"github.com/anilcse/cosmoscope/internal/price"
 	"github.com/anilcse/cosmoscope/pkg/utils"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
@@ -18,19 +17,45 @@ import (
 
 func QueryBalances(network config.EVMNetwork, address string, balanceChan chan<- portfolio.Balance) {
 	queryNativeBalance(network, address, balanceChan)
-	queryERC20Balances(network, address, balanceChan)
+	switch {
+	case len(network.Tokens) > 0:
+		queryMulticallBalances(network, address, balanceChan)
+	case config.GlobalConfig.MoralisAPIKey != "":
+		queryERC20Balances(network, address, balanceChan)
+	}
+}
+
+// ChainID returns the chain id reported by the RPC endpoint.
+func ChainID(rpc string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := ethclient.DialContext(ctx, rpc)
+	if err != nil {
+		return 0, err
+	}
+	defer client.Close()
+
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return chainID.Int64(), nil
 }
 
 func queryNativeBalance(network config.EVMNetwork, address string, balanceChan chan<- portfolio.Balance) {
 	client, err := ethclient.Dial(network.RPC)
 	if err != nil {
-		fmt.Printf("Error connecting to %s: %v\n", network.Name, err)
+		fmt.Fprintf(os.Stderr, "Error connecting to %s: %v\n", network.Name, err)
+		metrics.RecordFailure(network.RPC, "native")
 		return
 	}
 	defer client.Close()
 
 	balance, err := client.BalanceAt(context.Background(), common.HexToAddress(address), nil)
 	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error querying %s balance on %s: %v\n", network.NativeToken.Symbol, network.Name, err)
+		metrics.RecordFailure(network.RPC, "native")
 		return
 	}
 
@@ -43,83 +68,130 @@ func queryNativeBalance(network config.EVMNetwork, address string, balanceChan c
 	balanceChan <- portfolio.Balance{
 		Network:  network.Name,
 		Account:  address,
+		HexAddr:  hexAddress(address),
 		Token:    token.Symbol,
+		PriceID:  token.CoinGeckoID,
 		Amount:   amount,
-		USDValue: price.CalculateUSDValue(token.Symbol, amount),
 		Decimals: token.Decimals,
 	}
 }
 
-func queryERC20Balances(network config.EVMNetwork, address string, balanceChan chan<- portfolio.Balance) {
-	url := fmt.Sprintf("https://deep-index.moralis.io/api/v2/%s/erc20?chain=%s",
-		address, getChainName(network.ChainID))
-
-	req, _ := http.NewRequest("GET", url, nil)
-	req.Header.Add("Accept", "application/json")
-	req.Header.Add("X-API-Key", config.GlobalConfig.MoralisAPIKey)
+// queryMulticallBalances reads the configured tokens of network straight
+// from its RPC endpoint.
+func queryMulticallBalances(network config.EVMNetwork, address string, balanceChan chan<- portfolio.Balance) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
 
-	client := &http.Client{Timeout: time.Second * 10}
-	resp, err := client.Do(req)
+	client, err := ethclient.DialContext(ctx, network.RPC)
 	if err != nil {
-		fmt.Printf("Error querying Moralis API: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error connecting to %s: %v\n", network.Name, err)
+		metrics.RecordFailure(network.RPC, "multicall")
 		return
 	}
-	defer resp.Body.Close()
+	defer client.Close()
+
+	balances, err := NewMulticall(client, network.Multicall).TokenBalances(ctx, common.HexToAddress(address), withListMetadata(network))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error querying token balances on %s: %v\n", network.Name, err)
+		// A token that reverts is a configuration problem; only a failed
+		// call leaves the network's balances incomplete.
+		if balances == nil {
+			metrics.RecordFailure(network.RPC, "multicall")
+		}
+	}
+
+	for _, balance := range balances {
+		if balance.Amount.IsZero() {
+			continue
+		}
+
+		priceID := balance.Token.CoinGeckoID
+		if priceID == "" {
+			priceID = coingeckoIDForContract(network, balance.Token.Contract)
+		}
+
+		balanceChan <- portfolio.Balance{
+			Network:  network.Name,
+			Account:  address,
+			HexAddr:  hexAddress(address),
+			Token:    balance.Token.Symbol,
+			PriceID:  priceID,
+			Amount:   balance.Amount,
+			Decimals: *balance.Token.Decimals,
+		}
+	}
+}
 
-	var tokens []MoralisTokenBalance
-	if err := json.NewDecoder(resp.Body).Decode(&tokens); err != nil {
-		fmt.Printf("Error decoding Moralis response: %v\n", err)
+func queryERC20Balances(network config.EVMNetwork, address string, balanceChan chan<- portfolio.Balance) {
+	chain, ok := IndexerChain(network)
+	if !ok {
+		warnUnsupportedChain(network)
+		return
+	}
+	tokens, err := fetchMoralisTokens(address, chain)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error querying Moralis for %s on %s: %v\n", address, network.Name, err)
 		return
 	}
 
 	for _, token := range tokens {
-		if shouldSkipToken(token) {
+		// Tokens on a token list are vetted, so their metadata is used
+		// as is.
+		listed, onList := listedToken(network.ChainID, token.TokenAddress)
+		if reason := skipReason(network, token, onList); reason != "" {
+			recordFiltered(network, address, token, reason)
 			continue
 		}
 
+		priceID := coingeckoIDForContract(network, token.TokenAddress)
+		if onList {
+			token.Symbol, token.Decimals = listed.Symbol, *listed.Decimals
+			if priceID == "" {
+				priceID = listed.CoinGeckoID
+			}
+		} else {
+			token.Symbol = sanitizeSymbol(token.Symbol)
+		}
+
 		if token.Symbol == "POL" {
 			token.Symbol = "MATIC"
 		}
 
 		amount := utils.ParseAmount(token.Balance, token.Decimals)
-		if amount == 0 {
+		if amount.IsZero() {
 			continue
 		}
 
-		symbol := sanitizeSymbol(token.Symbol)
-		usdValue := price.CalculateUSDValue(symbol, amount)
-
 		balanceChan <- portfolio.Balance{
 			Network:  network.Name,
 			Account:  address,
-			Token:    symbol,
+			HexAddr:  hexAddress(address),
+			Token:    token.Symbol,
+			PriceID:  priceID,
 			Amount:   amount,
-			USDValue: usdValue,
 			Decimals: token.Decimals,
 		}
 	}
 }
 
-func shouldSkipToken(token MoralisTokenBalance) bool {
-	if token.PossibleSpam {
-		return true
-	}
-
-	suspiciousTerms := []string{
-		"visit", "claim", "bonus", "reward", "gift",
-		".com", ".org", ".net", ".tech", "http",
+// hexAddress returns the address bytes in the form Cosmos balances use for
+// HexAddr, so an Ethermint account and its 0x address group together.
+func hexAddress(address string) string {
+	if !common.IsHexAddress(address) {
+		return ""
 	}
+	return strings.ToLower(strings.TrimPrefix(common.HexToAddress(address).Hex(), "0x"))
+}
 
-	symbolLower := strings.ToLower(token.Symbol)
-	nameLower := strings.ToLower(token.Name)
-
-	for _, term := range suspiciousTerms {
-		if strings.Contains(symbolLower, term) || strings.Contains(nameLower, term) {
-			return true
+// coingeckoIDForContract looks up the configured CoinGecko ID of an ERC-20
+// contract, ignoring address checksum casing.
+func coingeckoIDForContract(network config.EVMNetwork, contract string) string {
+	for address, id := range network.CoinGeckoIDs {
+		if strings.EqualFold(address, contract) {
+			return id
 		}
 	}
-
-	return !token.VerifiedContract && token.SecurityScore == nil
+	return ""
 }
 
 func sanitizeSymbol(symbol string) string {
@@ -140,18 +212,3 @@ func sanitizeSymbol(symbol string) string {
 
 	return strings.TrimSpace(cleanSymbol)
 }
-
-func getChainName(chainID int) string {
-	chainMap := map[int]string{
-		1:     "eth",
-		137:   "polygon",
-		56:    "bsc",
-		42161: "arbitrum",
-		10:    "optimism",
-	}
-
-	if name, ok := chainMap[chainID]; ok {
-		return name
-	}
-	return fmt.Sprintf("0x%x", chainID)
-}
@@ -0,0 +1,251 @@
+package evm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anilcse/cosmoscope/internal/config"
+	"github.com/anilcse/cosmoscope/internal/metrics"
+)
+
+const (
+	// moralisMaxRetries is how often a rate-limited or failing request is
+	// retried before giving up.
+	moralisMaxRetries = 4
+	// moralisMaxPages guards against a cursor that never ends.
+	moralisMaxPages = 100
+	moralisMaxWait  = time.Minute
+)
+
+var (
+	moralisBaseURL = "https://deep-index.moralis.io/api/v2.2"
+	// moralisRetryWait is the first backoff delay when the response does
+	// not say how long to wait; it doubles on every retry.
+	moralisRetryWait = time.Second
+)
+
+// MoralisUsage counts the Moralis API requests made during the run.
+type MoralisUsage struct {
+	Calls       int
+	RateLimited int
+	Failed      int
+}
+
+var (
+	moralisMutex sync.Mutex
+	moralisUsage MoralisUsage
+)
+
+// MoralisCalls returns the Moralis API requests made so far.
+func MoralisCalls() MoralisUsage {
+	moralisMutex.Lock()
+	defer moralisMutex.Unlock()
+	return moralisUsage
+}
+
+func countMoralis(update func(*MoralisUsage)) {
+	moralisMutex.Lock()
+	before := moralisUsage
+	update(&moralisUsage)
+	after := moralisUsage
+	moralisMutex.Unlock()
+
+	metrics.RecordMoralis(after.Calls-before.Calls, after.RateLimited-before.RateLimited, after.Failed-before.Failed)
+}
+
+// moralisLimiter is shared by every Moralis request, so once a response
+// says the quota is spent no goroutine sends another request until it
+// resets.
+var moralisLimiter rateLimiter
+
+type rateLimiter struct {
+	mu    sync.Mutex
+	until time.Time
+}
+
+// wait blocks until requests may be sent again.
+func (l *rateLimiter) wait() {
+	l.mu.Lock()
+	until := l.until
+	l.mu.Unlock()
+	if d := time.Until(until); d > 0 {
+		time.Sleep(d)
+	}
+}
+
+// hold stops requests for d, unless they are already stopped for longer.
+func (l *rateLimiter) hold(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := time.Now().Add(d); until.After(l.until) {
+		l.until = until
+	}
+}
+
+// rateLimitPause returns how long to stop sending requests after a
+// response with header. Moralis reports the compute units used against
+// the plan limit and the per-second throttle in x-rate-limit-* headers,
+// with the seconds until each resets.
+func rateLimitPause(header http.Header) time.Duration {
+	spent := func(prefix string) (time.Duration, bool) {
+		used, err1 := strconv.Atoi(header.Get(prefix + "-Used"))
+		limit, err2 := strconv.Atoi(header.Get(prefix + "-Limit"))
+		if err1 != nil || err2 != nil || limit <= 0 || used < limit {
+			return 0, false
+		}
+		wait := time.Second
+		if ttl, err := strconv.Atoi(header.Get(prefix + "-Remaining-Ttl")); err == nil {
+			wait = time.Duration(ttl) * time.Second
+		}
+		if wait > moralisMaxWait {
+			wait = moralisMaxWait
+		}
+		return wait, true
+	}
+
+	if wait, ok := spent("X-Rate-Limit"); ok {
+		return wait
+	}
+	if wait, ok := spent("X-Rate-Limit-Throttle"); ok {
+		return wait
+	}
+	return 0
+}
+
+// fetchMoralisTokens returns the ERC-20 balances of address on chain,
+// following the cursor through every page.
+func fetchMoralisTokens(address, chain string) ([]MoralisTokenBalance, error) {
+	var tokens []MoralisTokenBalance
+	cursor := ""
+	for page := 0; page < moralisMaxPages; page++ {
+		query := url.Values{"chain": {chain}, "exclude_native": {"true"}}
+		if cursor != "" {
+			query.Set("cursor", cursor)
+		}
+		endpoint := fmt.Sprintf("%s/wallets/%s/tokens?%s", moralisBaseURL, address, query.Encode())
+
+		var response MoralisResponse
+		if err := moralisGet(endpoint, &response); err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, response.Result...)
+
+		if response.Cursor == "" {
+			return tokens, nil
+		}
+		cursor = response.Cursor
+	}
+	return nil, fmt.Errorf("more than %d pages of tokens", moralisMaxPages)
+}
+
+// moralisGet decodes the JSON response of endpoint into target. Rate
+// limited (429) and server error responses are retried with backoff,
+// honouring Retry-After when Moralis sends it. A rate limit holds back
+// every other Moralis request too.
+func moralisGet(endpoint string, target interface{}) error {
+	client := &http.Client{Timeout: time.Second * 10}
+	for attempt := 0; ; attempt++ {
+		moralisLimiter.wait()
+
+		req, err := http.NewRequest("GET", endpoint, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Add("Accept", "application/json")
+		req.Header.Add("X-API-Key", config.GlobalConfig.MoralisAPIKey)
+
+		start := time.Now()
+		resp, err := client.Do(req)
+		metrics.ObserveRequest(endpoint, time.Since(start))
+		countMoralis(func(u *MoralisUsage) { u.Calls++ })
+		if err != nil {
+			return moralisFailure(endpoint, err)
+		}
+		moralisLimiter.hold(rateLimitPause(resp.Header))
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return moralisFailure(endpoint, err)
+		}
+
+		switch {
+		case resp.StatusCode >= 200 && resp.StatusCode < 300:
+			if err := json.Unmarshal(body, target); err != nil {
+				return moralisFailure(endpoint, fmt.Errorf("decoding Moralis response: %v", err))
+			}
+			return nil
+		case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+			if resp.StatusCode == http.StatusTooManyRequests {
+				countMoralis(func(u *MoralisUsage) { u.RateLimited++ })
+			}
+			if attempt < moralisMaxRetries {
+				if resp.StatusCode == http.StatusTooManyRequests {
+					moralisLimiter.hold(retryDelay(resp.Header, attempt))
+				} else {
+					time.Sleep(retryDelay(resp.Header, attempt))
+				}
+				continue
+			}
+		}
+		return moralisFailure(endpoint, moralisStatusError(resp.StatusCode, body))
+	}
+}
+
+func moralisFailure(endpoint string, err error) error {
+	countMoralis(func(u *MoralisUsage) { u.Failed++ })
+	metrics.RecordFailure(endpoint, "erc20")
+	return err
+}
+
+// moralisStatusError describes an error response, including the message
+// Moralis puts in its JSON body.
+func moralisStatusError(status int, body []byte) error {
+	var response struct {
+		Message string `json:"message"`
+	}
+	message := strings.TrimSpace(string(body))
+	if json.Unmarshal(body, &response) == nil && response.Message != "" {
+		message = response.Message
+	}
+	if len(message) > 200 {
+		message = message[:200] + "..."
+	}
+
+	switch status {
+	case http.StatusUnauthorized:
+		return fmt.Errorf("Moralis rejected the API key (status 401): %s", message)
+	case http.StatusTooManyRequests:
+		return fmt.Errorf("Moralis rate limit still exceeded after %d retries: %s", moralisMaxRetries, message)
+	}
+	return fmt.Errorf("Moralis returned status %d: %s", status, message)
+}
+
+// retryDelay returns how long to wait before retry attempt+1: the
+// Retry-After header when present, otherwise exponential backoff.
+func retryDelay(header http.Header, attempt int) time.Duration {
+	wait := moralisRetryWait << attempt
+	if value := header.Get("Retry-After"); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil {
+			wait = time.Duration(seconds) * time.Second
+		} else if at, err := http.ParseTime(value); err == nil {
+			wait = time.Until(at)
+		}
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	if wait > moralisMaxWait {
+		wait = moralisMaxWait
+	}
+	return wait
+}
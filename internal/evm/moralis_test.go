@@ -0,0 +1,171 @@
+package evm
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anilcse/cosmoscope/internal/config"
+)
+
+// useMoralis points the Moralis client at handler for the duration of the
+// test and resets the call counts.
+func useMoralis(t *testing.T, handler http.HandlerFunc) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	baseURL, wait, key := moralisBaseURL, moralisRetryWait, config.GlobalConfig.MoralisAPIKey
+	moralisBaseURL, moralisRetryWait, config.GlobalConfig.MoralisAPIKey = server.URL, time.Millisecond, "test-key"
+	moralisUsage = MoralisUsage{}
+	moralisLimiter = rateLimiter{}
+	t.Cleanup(func() {
+		server.Close()
+		moralisBaseURL, moralisRetryWait, config.GlobalConfig.MoralisAPIKey = baseURL, wait, key
+	})
+}
+
+func TestFetchMoralisTokensPaginates(t *testing.T) {
+	useMoralis(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/wallets/0xabc/tokens" || r.Header.Get("X-API-Key") != "test-key" {
+			t.Errorf("unexpected request %s with key %q", r.URL, r.Header.Get("X-API-Key"))
+		}
+		if r.URL.Query().Get("chain") != "base" {
+			t.Errorf("chain = %q", r.URL.Query().Get("chain"))
+		}
+		switch r.URL.Query().Get("cursor") {
+		case "":
+			w.Write([]byte(`{"cursor": "page2", "result": [{"token_address": "0x1", "symbol": "AAA", "decimals": 18, "balance": "1"}]}`))
+		case "page2":
+			w.Write([]byte(`{"cursor": null, "result": [{"token_address": "0x2", "symbol": "BBB", "decimals": 6, "balance": "2"}]}`))
+		default:
+			t.Errorf("unexpected cursor %q", r.URL.Query().Get("cursor"))
+		}
+	})
+
+	tokens, err := fetchMoralisTokens("0xabc", "base")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 2 || tokens[0].Symbol != "AAA" || tokens[1].Symbol != "BBB" {
+		t.Errorf("tokens = %+v", tokens)
+	}
+	if usage := MoralisCalls(); usage.Calls != 2 || usage.Failed != 0 {
+		t.Errorf("usage = %+v", usage)
+	}
+}
+
+func TestFetchMoralisTokensRetriesRateLimit(t *testing.T) {
+	requests := 0
+	useMoralis(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"message": "Too many requests"}`))
+			return
+		}
+		w.Write([]byte(`{"result": []}`))
+	})
+
+	if _, err := fetchMoralisTokens("0xabc", "eth"); err != nil {
+		t.Fatal(err)
+	}
+	if usage := MoralisCalls(); usage.Calls != 3 || usage.RateLimited != 2 || usage.Failed != 0 {
+		t.Errorf("usage = %+v", usage)
+	}
+}
+
+func TestFetchMoralisTokensErrors(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		want   string
+	}{
+		{http.StatusUnauthorized, `{"message": "Invalid key"}`, "Moralis rejected the API key (status 401): Invalid key"},
+		{http.StatusBadRequest, `{"message": "Invalid chain"}`, "Moralis returned status 400: Invalid chain"},
+		{http.StatusTooManyRequests, `{"message": "Too many requests"}`, "rate limit still exceeded after 4 retries"},
+		{http.StatusOK, `[]`, "decoding Moralis response"},
+	}
+	for _, tt := range tests {
+		useMoralis(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			w.Write([]byte(tt.body))
+		})
+
+		_, err := fetchMoralisTokens("0xabc", "eth")
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("status %d: error = %v, want %q", tt.status, err, tt.want)
+		}
+		if usage := MoralisCalls(); usage.Failed != 1 {
+			t.Errorf("status %d: usage = %+v", tt.status, usage)
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	header := http.Header{}
+	if got := retryDelay(header, 2); got != 4*moralisRetryWait {
+		t.Errorf("backoff = %v, want %v", got, 4*moralisRetryWait)
+	}
+	header.Set("Retry-After", "7")
+	if got := retryDelay(header, 0); got != 7*time.Second {
+		t.Errorf("Retry-After seconds = %v", got)
+	}
+	header.Set("Retry-After", "3600")
+	if got := retryDelay(header, 0); got != moralisMaxWait {
+		t.Errorf("capped delay = %v, want %v", got, moralisMaxWait)
+	}
+}
+
+func TestRateLimitPause(t *testing.T) {
+	tests := []struct {
+		name   string
+		header map[string]string
+		want   time.Duration
+	}{
+		{"no headers", nil, 0},
+		{"quota left", map[string]string{"X-Rate-Limit-Limit": "100", "X-Rate-Limit-Used": "50", "X-Rate-Limit-Remaining-Ttl": "30"}, 0},
+		{"quota spent", map[string]string{"X-Rate-Limit-Limit": "100", "X-Rate-Limit-Used": "100", "X-Rate-Limit-Remaining-Ttl": "30"}, 30 * time.Second},
+		{"quota spent long", map[string]string{"X-Rate-Limit-Limit": "100", "X-Rate-Limit-Used": "120", "X-Rate-Limit-Remaining-Ttl": "86400"}, moralisMaxWait},
+		{"throttled", map[string]string{"X-Rate-Limit-Throttle-Limit": "25", "X-Rate-Limit-Throttle-Used": "25"}, time.Second},
+	}
+	for _, tt := range tests {
+		header := http.Header{}
+		for k, v := range tt.header {
+			header.Set(k, v)
+		}
+		if got := rateLimitPause(header); got != tt.want {
+			t.Errorf("%s: pause = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMoralisQuotaHoldsLaterRequests(t *testing.T) {
+	var mu sync.Mutex
+	var sent []time.Time
+	useMoralis(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		sent = append(sent, time.Now())
+		mu.Unlock()
+		w.Header().Set("X-Rate-Limit-Limit", "100")
+		w.Header().Set("X-Rate-Limit-Used", "100")
+		w.Header().Set("X-Rate-Limit-Remaining-Ttl", "1")
+		w.Write([]byte(`{"result": []}`))
+	})
+
+	// The first wallet spends the quota, so the request for the next one
+	// waits until it resets.
+	for _, address := range []string{"0xabc", "0xdef"} {
+		if _, err := fetchMoralisTokens(address, "eth"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(sent) != 2 {
+		t.Fatalf("sent %d requests, want 2", len(sent))
+	}
+	if gap := sent[1].Sub(sent[0]); gap < time.Second {
+		t.Errorf("second request sent after %v, want at least 1s", gap)
+	}
+}
@@ -0,0 +1,209 @@
+package evm
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/anilcse/cosmoscope/internal/config"
+	"github.com/anilcse/cosmoscope/pkg/utils"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Multicall3Address is where Multicall3 is deployed on most EVM chains.
+const Multicall3Address = "0xcA11bde05977b3631167028862bE2a173976CA11"
+
+// maxCallsPerBatch keeps each aggregate3 call well below RPC gas and
+// response size limits.
+const maxCallsPerBatch = 300
+
+const multicall3ABI = `[{"inputs":[{"components":[{"name":"target","type":"address"},{"name":"allowFailure","type":"bool"},{"name":"callData","type":"bytes"}],"name":"calls","type":"tuple[]"}],"name":"aggregate3","outputs":[{"components":[{"name":"success","type":"bool"},{"name":"returnData","type":"bytes"}],"name":"returnData","type":"tuple[]"}],"stateMutability":"payable","type":"function"}]`
+
+const erc20ABI = `[
+	{"inputs":[{"name":"account","type":"address"}],"name":"balanceOf","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"},
+	{"inputs":[],"name":"decimals","outputs":[{"name":"","type":"uint8"}],"stateMutability":"view","type":"function"},
+	{"inputs":[],"name":"symbol","outputs":[{"name":"","type":"string"}],"stateMutability":"view","type":"function"}
+]`
+
+var (
+	multicallABI = mustParseABI(multicall3ABI)
+	tokenABI     = mustParseABI(erc20ABI)
+)
+
+func mustParseABI(definition string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+// call3 and call3Result mirror the Multicall3 Call3 and Result structs.
+type call3 struct {
+	Target       common.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+type call3Result struct {
+	Success    bool
+	ReturnData []byte
+}
+
+// TokenBalance is the balance of one configured token, with the symbol and
+// decimals filled in from the contract when the configuration omits them, so
+// Token.Decimals is always set.
+type TokenBalance struct {
+	Token  config.EVMToken
+	Amount utils.Amount
+}
+
+// Multicall reads ERC-20 balances through a Multicall3 contract. Any
+// ethereum.ContractCaller works, including *ethclient.Client and the
+// go-ethereum simulated backend.
+type Multicall struct {
+	caller  ethereum.ContractCaller
+	address common.Address
+}
+
+// NewMulticall returns a Multicall using the contract at address, or at
+// Multicall3Address when address is empty.
+func NewMulticall(caller ethereum.ContractCaller, address string) *Multicall {
+	if address == "" {
+		address = Multicall3Address
+	}
+	return &Multicall{caller: caller, address: common.HexToAddress(address)}
+}
+
+// TokenBalances returns the balance of owner in every token. balanceOf,
+// decimals and symbol are requested together; a token whose calls fail is
+// reported in the returned error and left out, without failing the others.
+func (m *Multicall) TokenBalances(ctx context.Context, owner common.Address, tokens []config.EVMToken) ([]TokenBalance, error) {
+	balanceOf, err := tokenABI.Pack("balanceOf", owner)
+	if err != nil {
+		return nil, err
+	}
+	decimals, _ := tokenABI.Pack("decimals")
+	symbol, _ := tokenABI.Pack("symbol")
+
+	// Every token gets three consecutive calls so results can be matched
+	// back by index.
+	var calls []call3
+	for _, token := range tokens {
+		target := common.HexToAddress(token.Contract)
+		calls = append(calls,
+			call3{Target: target, AllowFailure: true, CallData: balanceOf},
+			call3{Target: target, AllowFailure: true, CallData: decimals},
+			call3{Target: target, AllowFailure: true, CallData: symbol},
+		)
+	}
+
+	results, err := m.aggregate(ctx, calls)
+	if err != nil {
+		return nil, err
+	}
+
+	var balances []TokenBalance
+	var failed []string
+	for i, token := range tokens {
+		balance, decimalsResult, symbolResult := results[3*i], results[3*i+1], results[3*i+2]
+
+		units, err := unpackUint(balance, "balanceOf")
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", token.Contract, err))
+			continue
+		}
+		if token.Decimals == nil {
+			d, err := unpackUint(decimalsResult, "decimals")
+			if err != nil {
+				failed = append(failed, fmt.Sprintf("%s: %v", token.Contract, err))
+				continue
+			}
+			decimals := int(d.Int64())
+			token.Decimals = &decimals
+		}
+		if token.Symbol == "" {
+			token.Symbol = unpackSymbol(symbolResult)
+		}
+		if token.Symbol == "" {
+			failed = append(failed, fmt.Sprintf("%s: no symbol configured or returned", token.Contract))
+			continue
+		}
+
+		balances = append(balances, TokenBalance{Token: token, Amount: utils.NewAmount(units, *token.Decimals)})
+	}
+
+	if len(failed) > 0 {
+		return balances, fmt.Errorf("token queries failed: %s", strings.Join(failed, "; "))
+	}
+	return balances, nil
+}
+
+// aggregate runs calls through aggregate3 in batches of maxCallsPerBatch.
+func (m *Multicall) aggregate(ctx context.Context, calls []call3) ([]call3Result, error) {
+	var results []call3Result
+	for start := 0; start < len(calls); start += maxCallsPerBatch {
+		end := start + maxCallsPerBatch
+		if end > len(calls) {
+			end = len(calls)
+		}
+
+		input, err := multicallABI.Pack("aggregate3", calls[start:end])
+		if err != nil {
+			return nil, err
+		}
+		output, err := m.caller.CallContract(ctx, ethereum.CallMsg{To: &m.address, Data: input}, nil)
+		if err != nil {
+			return nil, fmt.Errorf("calling multicall %s: %v", m.address.Hex(), err)
+		}
+		if len(output) == 0 {
+			return nil, fmt.Errorf("no multicall contract at %s", m.address.Hex())
+		}
+
+		unpacked, err := multicallABI.Unpack("aggregate3", output)
+		if err != nil {
+			return nil, fmt.Errorf("decoding multicall response: %v", err)
+		}
+		batch := *abi.ConvertType(unpacked[0], new([]call3Result)).(*[]call3Result)
+		if len(batch) != end-start {
+			return nil, fmt.Errorf("multicall returned %d results for %d calls", len(batch), end-start)
+		}
+		results = append(results, batch...)
+	}
+	return results, nil
+}
+
+func unpackUint(result call3Result, method string) (*big.Int, error) {
+	if !result.Success {
+		return nil, fmt.Errorf("%s reverted", method)
+	}
+	values, err := tokenABI.Unpack(method, result.ReturnData)
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s: %v", method, err)
+	}
+	switch v := values[0].(type) {
+	case *big.Int:
+		return v, nil
+	case uint8:
+		return big.NewInt(int64(v)), nil
+	}
+	return nil, fmt.Errorf("unexpected %s result %T", method, values[0])
+}
+
+// unpackSymbol decodes a string symbol, or the bytes32 symbol some older
+// tokens such as MKR return.
+func unpackSymbol(result call3Result) string {
+	if !result.Success {
+		return ""
+	}
+	if values, err := tokenABI.Unpack("symbol", result.ReturnData); err == nil {
+		return strings.TrimSpace(values[0].(string))
+	}
+	if len(result.ReturnData) == 32 {
+		return strings.TrimSpace(strings.TrimRight(string(result.ReturnData), "\x00"))
+	}
+	return ""
+}
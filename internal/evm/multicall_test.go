@@ -0,0 +1,269 @@
+package evm
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/anilcse/cosmoscope/internal/config"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/asm"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// fakeToken answers the ERC-20 calls of one contract. A nil balance makes
+// balanceOf revert and an empty symbol makes symbol revert.
+type fakeToken struct {
+	balance  *big.Int
+	decimals uint8
+	symbol   string
+	bytes32  bool
+}
+
+// fakeMulticall executes aggregate3 against fake tokens in memory.
+type fakeMulticall struct {
+	tokens map[common.Address]fakeToken
+	calls  int
+}
+
+func (f *fakeMulticall) CallContract(_ context.Context, msg ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+	f.calls++
+	method := multicallABI.Methods["aggregate3"]
+	args, err := method.Inputs.Unpack(msg.Data[4:])
+	if err != nil {
+		return nil, err
+	}
+	calls := *abi.ConvertType(args[0], new([]call3)).(*[]call3)
+
+	var results []call3Result
+	for _, call := range calls {
+		token, ok := f.tokens[call.Target]
+		result := call3Result{}
+		if ok {
+			result.ReturnData, result.Success = token.answer(call.CallData)
+		}
+		results = append(results, result)
+	}
+	return method.Outputs.Pack(results)
+}
+
+func (t fakeToken) answer(data []byte) ([]byte, bool) {
+	method, err := tokenABI.MethodById(data[:4])
+	if err != nil {
+		return nil, false
+	}
+	switch method.Name {
+	case "balanceOf":
+		if t.balance == nil {
+			return nil, false
+		}
+		out, _ := method.Outputs.Pack(t.balance)
+		return out, true
+	case "decimals":
+		out, _ := method.Outputs.Pack(t.decimals)
+		return out, true
+	case "symbol":
+		if t.symbol == "" {
+			return nil, false
+		}
+		if t.bytes32 {
+			var padded [32]byte
+			copy(padded[:], t.symbol)
+			return padded[:], true
+		}
+		out, _ := method.Outputs.Pack(t.symbol)
+		return out, true
+	}
+	return nil, false
+}
+
+func TestTokenBalances(t *testing.T) {
+	usdc := common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	mkr := common.HexToAddress("0x9f8F72aA9304c8B593d555F12eF6589cC3A579A2")
+	broken := common.HexToAddress("0x0000000000000000000000000000000000000bad")
+
+	caller := &fakeMulticall{tokens: map[common.Address]fakeToken{
+		usdc:   {balance: big.NewInt(1_500_000), decimals: 6, symbol: "USDC"},
+		mkr:    {balance: new(big.Int).Mul(big.NewInt(2), big.NewInt(1e18)), decimals: 18, symbol: "MKR", bytes32: true},
+		broken: {decimals: 18, symbol: "BAD"},
+	}}
+
+	tokens := []config.EVMToken{
+		{Contract: usdc.Hex(), Symbol: "USDC.e", Decimals: decimals(6), CoinGeckoID: "usd-coin"},
+		{Contract: mkr.Hex()},
+		{Contract: broken.Hex()},
+	}
+	balances, err := NewMulticall(caller, "").TokenBalances(context.Background(), common.HexToAddress("0x1"), tokens)
+	if err == nil || !strings.Contains(err.Error(), "balanceOf reverted") {
+		t.Errorf("error = %v, want the broken token reported", err)
+	}
+	if caller.calls != 1 {
+		t.Errorf("made %d RPC calls, want 1", caller.calls)
+	}
+
+	if len(balances) != 2 {
+		t.Fatalf("got %d balances, want 2: %+v", len(balances), balances)
+	}
+	if got := balances[0]; got.Token.Symbol != "USDC.e" || got.Amount.String() != "1.5" || got.Token.CoinGeckoID != "usd-coin" {
+		t.Errorf("configured token = %+v %s", got.Token, got.Amount)
+	}
+	if got := balances[1]; got.Token.Symbol != "MKR" || *got.Token.Decimals != 18 || got.Amount.String() != "2" {
+		t.Errorf("token read from contract = %+v %s", got.Token, got.Amount)
+	}
+}
+
+func TestTokenBalancesBatches(t *testing.T) {
+	caller := &fakeMulticall{tokens: make(map[common.Address]fakeToken)}
+	var tokens []config.EVMToken
+	for i := 1; i <= 250; i++ {
+		address := common.BigToAddress(big.NewInt(int64(i)))
+		caller.tokens[address] = fakeToken{balance: big.NewInt(int64(i)), decimals: 0, symbol: "T"}
+		tokens = append(tokens, config.EVMToken{Contract: address.Hex()})
+	}
+
+	balances, err := NewMulticall(caller, "").TokenBalances(context.Background(), common.HexToAddress("0x1"), tokens)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if caller.calls != 3 {
+		t.Errorf("made %d RPC calls for 750 token calls, want 3", caller.calls)
+	}
+	if len(balances) != 250 || balances[249].Amount.String() != "250" {
+		t.Errorf("got %d balances, last %s", len(balances), balances[len(balances)-1].Amount)
+	}
+}
+
+func TestTokenBalancesMissingContract(t *testing.T) {
+	empty := callerFunc(func(ethereum.CallMsg) ([]byte, error) { return nil, nil })
+	_, err := NewMulticall(empty, "").TokenBalances(context.Background(), common.Address{}, []config.EVMToken{{Contract: "0x1"}})
+	if err == nil || !strings.Contains(err.Error(), "no multicall contract") {
+		t.Errorf("error = %v, want missing contract", err)
+	}
+}
+
+// simulatedToken answers balanceOf with 1.5 USDC, decimals with 6 and
+// symbol with "USDC", whatever the arguments.
+const simulatedToken = `
+	PUSH 0
+	CALLDATALOAD
+	PUSH 0xe0
+	SHR
+	DUP1
+	PUSH 0x70a08231
+	EQ
+	JUMPI @balance
+	DUP1
+	PUSH 0x313ce567
+	EQ
+	JUMPI @decimals
+	PUSH 0x95d89b41
+	EQ
+	JUMPI @symbol
+	PUSH 0
+	DUP1
+	REVERT
+balance:
+	PUSH 1500000
+	PUSH 0
+	MSTORE
+	PUSH 0x20
+	PUSH 0
+	RETURN
+decimals:
+	PUSH 6
+	PUSH 0
+	MSTORE
+	PUSH 0x20
+	PUSH 0
+	RETURN
+symbol:
+	PUSH 0x20
+	PUSH 0
+	MSTORE
+	PUSH 4
+	PUSH 0x20
+	MSTORE
+	PUSH "USDC"
+	PUSH 0xe0
+	SHL
+	PUSH 0x40
+	MSTORE
+	PUSH 0x60
+	PUSH 0
+	RETURN
+`
+
+// TestTokenBalancesSimulated runs TokenBalances against the Multicall3
+// contract itself. testdata/Multicall3.bin holds its creation bytecode as
+// published at github.com/mds1/multicall.
+func TestTokenBalancesSimulated(t *testing.T) {
+	bytecode, err := os.ReadFile("testdata/Multicall3.bin")
+	if os.IsNotExist(err) {
+		t.Skip("testdata/Multicall3.bin is missing")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, _ := crypto.GenerateKey()
+	deployer := crypto.PubkeyToAddress(key.PublicKey)
+	token := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	reverting := common.HexToAddress("0x00000000000000000000000000000000000000bb")
+
+	backend := backends.NewSimulatedBackend(core.GenesisAlloc{
+		deployer:  {Balance: new(big.Int).Mul(big.NewInt(1e18), big.NewInt(1e18))},
+		token:     {Code: assemble(t, simulatedToken)},
+		reverting: {Code: assemble(t, "PUSH 0\nDUP1\nREVERT\n")},
+	}, 30_000_000)
+	defer backend.Close()
+
+	auth, err := bind.NewKeyedTransactorWithChainID(key, big.NewInt(1337))
+	if err != nil {
+		t.Fatal(err)
+	}
+	address, _, _, err := bind.DeployContract(auth, multicallABI, common.FromHex(strings.TrimSpace(string(bytecode))), backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend.Commit()
+
+	tokens := []config.EVMToken{{Contract: token.Hex()}, {Contract: reverting.Hex()}}
+	balances, err := NewMulticall(backend, address.Hex()).TokenBalances(context.Background(), deployer, tokens)
+	if err == nil || !strings.Contains(err.Error(), "balanceOf reverted") {
+		t.Errorf("error = %v, want the reverting token reported", err)
+	}
+	if len(balances) != 1 {
+		t.Fatalf("got %d balances, want 1: %+v", len(balances), balances)
+	}
+	if got := balances[0]; got.Token.Symbol != "USDC" || *got.Token.Decimals != 6 || got.Amount.String() != "1.5" {
+		t.Errorf("balance = %+v %s", got.Token, got.Amount)
+	}
+}
+
+func assemble(t *testing.T, source string) []byte {
+	t.Helper()
+	compiler := asm.NewCompiler(false)
+	compiler.Feed(asm.Lex([]byte(source), false))
+	code, errs := compiler.Compile()
+	if len(errs) > 0 {
+		t.Fatalf("assembling token: %v", errs)
+	}
+	return common.FromHex(code)
+}
+
+func decimals(d int) *int {
+	return &d
+}
+
+type callerFunc func(ethereum.CallMsg) ([]byte, error)
+
+func (f callerFunc) CallContract(_ context.Context, msg ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+	return f(msg)
+}
@@ -0,0 +1,117 @@
+package evm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/anilcse/cosmoscope/internal/config"
+	"github.com/anilcse/cosmoscope/internal/portfolio"
+)
+
+var suspiciousTerms = []string{
+	"visit", "claim", "bonus", "reward", "gift",
+	".com", ".org", ".net", ".tech", "http",
+}
+
+var (
+	filteredMutex sync.Mutex
+	// filteredTokens are the tokens left out of reports, by network,
+	// account and contract.
+	filteredTokens = make(map[string]portfolio.FilteredToken)
+)
+
+// ResetFilteredTokens forgets the filtered tokens of earlier queries, so
+// FilteredTokens only reports those of the current scan.
+func ResetFilteredTokens() {
+	filteredMutex.Lock()
+	defer filteredMutex.Unlock()
+	filteredTokens = make(map[string]portfolio.FilteredToken)
+}
+
+// FilteredTokens returns every token the spam policies left out, ordered by
+// network, account and symbol.
+func FilteredTokens() []portfolio.FilteredToken {
+	filteredMutex.Lock()
+	defer filteredMutex.Unlock()
+
+	tokens := make([]portfolio.FilteredToken, 0, len(filteredTokens))
+	for _, token := range filteredTokens {
+		tokens = append(tokens, token)
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		a, b := tokens[i], tokens[j]
+		if a.Network != b.Network {
+			return a.Network < b.Network
+		}
+		if a.Account != b.Account {
+			return a.Account < b.Account
+		}
+		return a.Symbol < b.Symbol
+	})
+	return tokens
+}
+
+func recordFiltered(network config.EVMNetwork, account string, token MoralisTokenBalance, reason string) {
+	filteredMutex.Lock()
+	defer filteredMutex.Unlock()
+
+	key := strings.ToLower(network.Name + "/" + account + "/" + token.TokenAddress)
+	filteredTokens[key] = portfolio.FilteredToken{
+		Network:  network.Name,
+		Account:  account,
+		Contract: token.TokenAddress,
+		Symbol:   token.Symbol,
+		Reason:   reason,
+	}
+}
+
+// skipReason returns why token should be left out of the balances of
+// network, or "" to keep it. The deny and allow lists come first, then
+// token list membership, the unlisted token policy and the heuristics.
+func skipReason(network config.EVMNetwork, token MoralisTokenBalance, listed bool) string {
+	policy := network.SpamPolicy
+	switch {
+	case containsAddress(policy.Deny, token.TokenAddress):
+		return "on the deny list"
+	case containsAddress(policy.Allow, token.TokenAddress), listed:
+		return ""
+	}
+
+	switch network.UnlistedTokens {
+	case config.UnlistedInclude:
+		return ""
+	case config.UnlistedExclude:
+		return "not on a token list"
+	}
+
+	if policy.Enabled(config.HeuristicPossibleSpam) && token.PossibleSpam {
+		return "flagged as possible spam by Moralis"
+	}
+	if policy.Enabled(config.HeuristicSuspiciousName) {
+		symbolLower := strings.ToLower(token.Symbol)
+		nameLower := strings.ToLower(token.Name)
+		for _, term := range suspiciousTerms {
+			if strings.Contains(symbolLower, term) || strings.Contains(nameLower, term) {
+				return fmt.Sprintf("name or symbol contains %q", term)
+			}
+		}
+	}
+	if policy.MinSecurityScore > 0 && token.SecurityScore != nil && *token.SecurityScore < policy.MinSecurityScore {
+		return fmt.Sprintf("security score %d is below %d", *token.SecurityScore, policy.MinSecurityScore)
+	}
+	if policy.Enabled(config.HeuristicUnverified) && !token.VerifiedContract && token.SecurityScore == nil {
+		return "unverified contract without a security score"
+	}
+	return ""
+}
+
+func containsAddress(addresses []string, address string) bool {
+	for _, a := range addresses {
+		if strings.EqualFold(a, address) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package evm
+
+import (
+	"testing"
+
+	"github.com/anilcse/cosmoscope/internal/config"
+)
+
+func TestSkipReason(t *testing.T) {
+	const contract = "0x1111111111111111111111111111111111111111"
+	score := func(s int) *int { return &s }
+
+	unverified := MoralisTokenBalance{TokenAddress: contract, Symbol: "HOLD"}
+	spam := MoralisTokenBalance{TokenAddress: contract, Symbol: "SPAM", PossibleSpam: true}
+	advert := MoralisTokenBalance{TokenAddress: contract, Symbol: "X", Name: "Visit x.com to claim", VerifiedContract: true}
+	lowScore := MoralisTokenBalance{TokenAddress: contract, Symbol: "LOW", SecurityScore: score(20)}
+
+	tests := []struct {
+		name    string
+		network config.EVMNetwork
+		token   MoralisTokenBalance
+		listed  bool
+		want    string
+	}{
+		{"unverified by default", config.EVMNetwork{}, unverified, false, "unverified contract without a security score"},
+		{"unverified heuristic off", config.EVMNetwork{SpamPolicy: config.TokenPolicy{Heuristics: map[string]bool{config.HeuristicUnverified: false}}}, unverified, false, ""},
+		{"allow list", config.EVMNetwork{SpamPolicy: config.TokenPolicy{Allow: []string{"0x1111111111111111111111111111111111111111"}}}, spam, false, ""},
+		{"deny list beats token list", config.EVMNetwork{SpamPolicy: config.TokenPolicy{Deny: []string{contract}}}, unverified, true, "on the deny list"},
+		{"listed", config.EVMNetwork{}, spam, true, ""},
+		{"possible spam", config.EVMNetwork{}, spam, false, "flagged as possible spam by Moralis"},
+		{"suspicious name", config.EVMNetwork{}, advert, false, `name or symbol contains "visit"`},
+		{"suspicious name off", config.EVMNetwork{SpamPolicy: config.TokenPolicy{Heuristics: map[string]bool{config.HeuristicSuspiciousName: false}}}, advert, false, ""},
+		{"security score", config.EVMNetwork{SpamPolicy: config.TokenPolicy{MinSecurityScore: 50}}, lowScore, false, "security score 20 is below 50"},
+		{"unlisted include", config.EVMNetwork{UnlistedTokens: config.UnlistedInclude}, spam, false, ""},
+		{"unlisted exclude", config.EVMNetwork{UnlistedTokens: config.UnlistedExclude}, advert, false, "not on a token list"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := skipReason(tt.network, tt.token, tt.listed); got != tt.want {
+				t.Errorf("skipReason = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResetFilteredTokens(t *testing.T) {
+	network := config.EVMNetwork{Name: "ethereum"}
+	recordFiltered(network, "0xabc", MoralisTokenBalance{TokenAddress: "0xdead", Symbol: "FREE"}, "on the deny list")
+	if got := FilteredTokens(); len(got) != 1 || got[0].Symbol != "FREE" {
+		t.Fatalf("FilteredTokens = %+v", got)
+	}
+
+	ResetFilteredTokens()
+	if got := FilteredTokens(); len(got) != 0 {
+		t.Errorf("FilteredTokens after reset = %+v", got)
+	}
+}
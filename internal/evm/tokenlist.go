@@ -0,0 +1,50 @@
+package evm
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/anilcse/cosmoscope/internal/config"
+)
+
+var (
+	tokenListMutex sync.RWMutex
+	// tokenList holds the vetted tokens by chain ID and lowercase contract.
+	tokenList map[int]map[string]config.EVMToken
+)
+
+// SetTokenList installs the tokens loaded from the configured token lists.
+func SetTokenList(tokens map[int]map[string]config.EVMToken) {
+	tokenListMutex.Lock()
+	tokenList = tokens
+	tokenListMutex.Unlock()
+}
+
+func listedToken(chainID int, contract string) (config.EVMToken, bool) {
+	tokenListMutex.RLock()
+	defer tokenListMutex.RUnlock()
+
+	token, ok := tokenList[chainID][strings.ToLower(contract)]
+	return token, ok
+}
+
+// withListMetadata fills in the symbol, decimals and CoinGecko ID of
+// configured tokens that leave them out from the token lists.
+func withListMetadata(network config.EVMNetwork) []config.EVMToken {
+	tokens := make([]config.EVMToken, len(network.Tokens))
+	for i, token := range network.Tokens {
+		if listed, ok := listedToken(network.ChainID, token.Contract); ok {
+			if token.Symbol == "" {
+				token.Symbol = listed.Symbol
+			}
+			if token.Decimals == nil {
+				token.Decimals = listed.Decimals
+			}
+			if token.CoinGeckoID == "" {
+				token.CoinGeckoID = listed.CoinGeckoID
+			}
+		}
+		tokens[i] = token
+	}
+	return tokens
+}
@@ -0,0 +1,50 @@
+package evm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/anilcse/cosmoscope/internal/config"
+)
+
+const testTokenList = `{
+  "name": "Test List",
+  "tokens": [
+    {"chainId": 1, "address": "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48", "symbol": "USDC", "decimals": 6, "extensions": {"coingeckoId": "usd-coin"}},
+    {"chainId": 10, "address": "0x0b2C639c533813f4Aa9D7837CAf62653d097Ff85", "symbol": "USDC", "decimals": 6}
+  ]
+}`
+
+func TestTokenList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	if err := os.WriteFile(path, []byte(testTokenList), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tokens, err := config.LoadTokenLists([]string{path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetTokenList(tokens)
+	defer SetTokenList(nil)
+
+	listed, ok := listedToken(1, "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48")
+	if !ok || listed.Symbol != "USDC" || listed.Decimals == nil || *listed.Decimals != 6 || listed.CoinGeckoID != "usd-coin" {
+		t.Errorf("listedToken = %+v, %v", listed, ok)
+	}
+	if _, ok := listedToken(137, "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"); ok {
+		t.Error("token listed for chain 1 was found on chain 137")
+	}
+
+	network := config.EVMNetwork{ChainID: 1, Tokens: []config.EVMToken{
+		{Contract: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48", Symbol: "USDC.e"},
+		{Contract: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48", Decimals: decimals(0)},
+	}}
+	got := withListMetadata(network)
+	if got[0].Symbol != "USDC.e" || got[0].Decimals == nil || *got[0].Decimals != 6 || got[0].CoinGeckoID != "usd-coin" {
+		t.Errorf("withListMetadata = %+v", got[0])
+	}
+	if got[1].Decimals == nil || *got[1].Decimals != 0 {
+		t.Errorf("configured zero decimals were replaced: %+v", got[1])
+	}
+}
@@ -16,4 +16,8 @@ type MoralisTokenBalance struct {
 	SecurityScore                   *int     `json:"security_score"`
 }
 
-type MoralisResponse []MoralisTokenBalance
+// MoralisResponse is one page of the wallet token balances endpoint.
+type MoralisResponse struct {
+	Cursor string                `json:"cursor"`
+	Result []MoralisTokenBalance `json:"result"`
+}
@@ -0,0 +1,207 @@
+// Package metrics exposes portfolio values and scrape health as Prometheus
+// metrics for the exporter command.
+package metrics
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/anilcse/cosmoscope/internal/portfolio"
+	"github.com/anilcse/cosmoscope/internal/price"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "cosmoscope"
+
+// Registry holds every cosmoscope metric. It is separate from the default
+// registry so only cosmoscope metrics are exported.
+var Registry = prometheus.NewRegistry()
+
+var (
+	balanceLabels = []string{"account", "network", "token", "type"}
+
+	balanceUSD = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "balance_usd",
+		Help:      "USD value of a balance.",
+	}, balanceLabels)
+
+	balanceAmount = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "balance_amount",
+		Help:      "Token amount of a balance.",
+	}, balanceLabels)
+
+	portfolioUSD = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "portfolio_usd",
+		Help:      "Total USD value of the portfolio.",
+	})
+
+	tokenPriceUSD = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "token_price_usd",
+		Help:      "USD price of a token.",
+	}, []string{"token", "price_id", "source"})
+
+	lastRefresh = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "last_refresh_timestamp_seconds",
+		Help:      "Unix time the balances were last refreshed.",
+	})
+
+	refreshDuration = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "refresh_duration_seconds",
+		Help:      "How long the last balance refresh took.",
+	})
+
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "endpoint_request_duration_seconds",
+		Help:      "Latency of requests to chain REST and API endpoints.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	}, []string{"endpoint"})
+
+	requestFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "endpoint_request_failures_total",
+		Help:      "Failed balance queries by endpoint and query.",
+	}, []string{"endpoint", "query"})
+
+	endpointSelections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "endpoint_selections_total",
+		Help:      "REST endpoints selected for each network.",
+	}, []string{"network", "endpoint"})
+
+	moralisRequests = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "moralis_requests_total",
+		Help:      "Requests made to the Moralis API.",
+	})
+
+	moralisRateLimited = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "moralis_rate_limited_total",
+		Help:      "Moralis responses rejected by its rate limit.",
+	})
+
+	moralisFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "moralis_failures_total",
+		Help:      "Moralis queries that failed after retries.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		balanceUSD,
+		balanceAmount,
+		portfolioUSD,
+		tokenPriceUSD,
+		lastRefresh,
+		refreshDuration,
+		requestDuration,
+		requestFailures,
+		endpointSelections,
+		moralisRequests,
+		moralisRateLimited,
+		moralisFailures,
+	)
+}
+
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// SetBalances replaces the balance gauges. Balances sharing a label set,
+// such as several unbonding entries, are summed.
+func SetBalances(balances []portfolio.Balance) {
+	type labels struct{ account, network, token, assetType string }
+	usd := make(map[labels]float64)
+	amounts := make(map[labels]float64)
+
+	var total float64
+	for _, b := range balances {
+		key := labels{
+			account:   b.Account,
+			network:   strings.Split(b.Network, "-")[0],
+			token:     b.Token,
+			assetType: portfolio.AssetType(b),
+		}
+		usd[key] += b.USDValue.Float64()
+		amounts[key] += b.Amount.Float64()
+		total += b.USDValue.Float64()
+	}
+
+	balanceUSD.Reset()
+	balanceAmount.Reset()
+	for key, value := range usd {
+		balanceUSD.WithLabelValues(key.account, key.network, key.token, key.assetType).Set(value)
+		balanceAmount.WithLabelValues(key.account, key.network, key.token, key.assetType).Set(amounts[key])
+	}
+	portfolioUSD.Set(total)
+}
+
+// SetPrices replaces the price gauges with the loaded prices of tokens.
+func SetPrices(tokens []price.Token) {
+	tokenPriceUSD.Reset()
+	for _, token := range tokens {
+		if p, source, ok := price.Lookup(token); ok {
+			tokenPriceUSD.WithLabelValues(strings.ToUpper(token.Symbol), token.ID, source).Set(p)
+		}
+	}
+}
+
+// ObserveRefresh records when a balance refresh finished and how long it took.
+func ObserveRefresh(started time.Time) {
+	lastRefresh.Set(float64(time.Now().Unix()))
+	refreshDuration.Set(time.Since(started).Seconds())
+}
+
+// ObserveRequest records the latency of a request to rawURL. Requests are
+// labelled by host so the label set stays small.
+func ObserveRequest(rawURL string, duration time.Duration) {
+	requestDuration.WithLabelValues(host(rawURL)).Observe(duration.Seconds())
+}
+
+// failures is the total of requestFailures, readable without scraping.
+var failures atomic.Int64
+
+// RecordFailure counts a failed query against the endpoint of rawURL.
+func RecordFailure(rawURL, query string) {
+	requestFailures.WithLabelValues(host(rawURL), query).Inc()
+	failures.Add(1)
+}
+
+// Failures returns how many queries have failed since the process started,
+// so callers can tell whether a refresh was complete.
+func Failures() int64 {
+	return failures.Load()
+}
+
+// RecordEndpointSelection counts the REST endpoint chosen for network.
+func RecordEndpointSelection(network, endpoint string) {
+	endpointSelections.WithLabelValues(network, host(endpoint)).Inc()
+}
+
+// RecordMoralis counts Moralis API requests, rate-limited responses and
+// failed queries.
+func RecordMoralis(requests, rateLimited, failed int) {
+	moralisRequests.Add(float64(requests))
+	moralisRateLimited.Add(float64(rateLimited))
+	moralisFailures.Add(float64(failed))
+}
+
+func host(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	return u.Host
+}
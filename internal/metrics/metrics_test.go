@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anilcse/cosmoscope/internal/portfolio"
+	"github.com/anilcse/cosmoscope/pkg/utils"
+)
+
+func TestHandler(t *testing.T) {
+	SetBalances([]portfolio.Balance{
+		{Account: "cosmos1a", Network: "cosmoshub-bank", Token: "ATOM", Amount: utils.ParseAmount("10", 0), USDValue: utils.ParseAmount("100", 0)},
+		{Account: "cosmos1a", Network: "cosmoshub-unbonding", Token: "ATOM", Amount: utils.ParseAmount("1", 0), USDValue: utils.ParseAmount("10", 0)},
+		{Account: "cosmos1a", Network: "cosmoshub-unbonding", Token: "ATOM", Amount: utils.ParseAmount("2", 0), USDValue: utils.ParseAmount("20", 0)},
+	})
+	ObserveRequest("https://rest.example.com/cosmos/bank/v1beta1/balances/cosmos1a", 120*time.Millisecond)
+	failures := Failures()
+	RecordFailure("https://rest.example.com", "rewards")
+	if got := Failures() - failures; got != 1 {
+		t.Errorf("Failures() grew by %d, want 1", got)
+	}
+	RecordEndpointSelection("cosmoshub", "https://rest.example.com")
+	RecordMoralis(3, 1, 0)
+
+	server := httptest.NewServer(Handler())
+	defer server.Close()
+
+	resp, err := server.Client().Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		`cosmoscope_balance_usd{account="cosmos1a",network="cosmoshub",token="ATOM",type="Bank"} 100`,
+		`cosmoscope_balance_usd{account="cosmos1a",network="cosmoshub",token="ATOM",type="Unbonding"} 30`,
+		`cosmoscope_balance_amount{account="cosmos1a",network="cosmoshub",token="ATOM",type="Unbonding"} 3`,
+		`cosmoscope_portfolio_usd 130`,
+		`cosmoscope_endpoint_request_duration_seconds_count{endpoint="rest.example.com"} 1`,
+		`cosmoscope_endpoint_request_failures_total{endpoint="rest.example.com",query="rewards"} 1`,
+		`cosmoscope_endpoint_selections_total{endpoint="rest.example.com",network="cosmoshub"} 1`,
+		`cosmoscope_moralis_requests_total 3`,
+		`cosmoscope_moralis_rate_limited_total 1`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("metrics output missing %s", want)
+		}
+	}
+}
@@ -1,37 +1,107 @@
 package portfolio
 
 import (
+	"sort"
+	"strings"
+	"time"
+
 	"github.com/anilcse/cosmoscope/internal/config"
 	"github.com/anilcse/cosmoscope/internal/price"
+	"github.com/anilcse/cosmoscope/pkg/utils"
 )
 
+// dustThreshold is the USD value below which balances are dropped from the report.
+var dustThreshold = utils.AmountFromFloat(0.01)
+
 type Balance struct {
-	Network  string
-	Account  string
-	HexAddr  string
-	Token    string
-	Amount   float64
-	USDValue float64
-	Decimals int
+	Network  string       `json:"network"`
+	Account  string       `json:"account"`
+	HexAddr  string       `json:"hex_addr,omitempty"`
+	Token    string       `json:"token"`
+	Amount   utils.Amount `json:"amount"`
+	USDValue utils.Amount `json:"usd_value"`
+	Decimals int          `json:"decimals"`
+	// PriceID is the CoinGecko ID of the token when it is known.
+	PriceID string `json:"price_id,omitempty"`
+	// PriceSource names the price provider that valued the balance.
+	PriceSource string `json:"price_source,omitempty"`
+	// CompletionTime is set for balances that are locked until a known
+	// time, such as unbonding and redelegation entries, and nil otherwise.
+	CompletionTime *time.Time `json:"completion_time,omitempty"`
+	// Unlocks is the projected release schedule of a locked vesting balance.
+	Unlocks []Unlock `json:"unlocks,omitempty"`
+	// Label, Owner and Tags come from the configured address entry.
+	Label string   `json:"label,omitempty"`
+	Owner string   `json:"owner,omitempty"`
+	Tags  []string `json:"tags,omitempty"`
+}
+
+// AccountName is the label of the account, or its address when unlabelled.
+func (b Balance) AccountName() string {
+	if b.Label != "" {
+		return b.Label
+	}
+	return b.Account
+}
+
+type Unlock struct {
+	Time   time.Time    `json:"time"`
+	Amount utils.Amount `json:"amount"`
+}
+
+// FilteredToken is a token balance left out of the report by a spam policy.
+type FilteredToken struct {
+	Network  string `json:"network"`
+	Account  string `json:"account"`
+	Contract string `json:"contract"`
+	Symbol   string `json:"symbol"`
+	Reason   string `json:"reason"`
 }
 
 type TokenSummary struct {
-	TokenName string
-	Balance   float64
-	USDValue  float64
-	Share     float64
+	TokenName string       `json:"token"`
+	Balance   utils.Amount `json:"amount"`
+	USDValue  utils.Amount `json:"usd_value"`
+	Share     float64      `json:"share_pct"`
 }
 
 func CollectBalances(balanceChan chan Balance) []Balance {
 	var balances []Balance
 	for balance := range balanceChan {
-		if balance.USDValue > 0.01 {
-			balances = append(balances, balance)
-		}
+		balances = append(balances, balance)
 	}
 	return balances
 }
 
+// PriceTokens returns what needs to be priced to value balances.
+func PriceTokens(balances []Balance) []price.Token {
+	var tokens []price.Token
+	for _, balance := range balances {
+		tokens = append(tokens, balance.priceToken())
+	}
+	return tokens
+}
+
+func (b Balance) priceToken() price.Token {
+	return price.Token{ID: b.PriceID, Symbol: b.Token}
+}
+
+// PriceBalances values every balance with the loaded prices and drops
+// balances worth less than the dust threshold.
+func PriceBalances(balances []Balance) []Balance {
+	var priced []Balance
+	for _, balance := range balances {
+		if p, source, ok := price.Lookup(balance.priceToken()); ok {
+			balance.USDValue = balance.Amount.Mul(utils.AmountFromFloat(p))
+			balance.PriceSource = source
+		}
+		if balance.USDValue.Cmp(dustThreshold) > 0 {
+			priced = append(priced, balance)
+		}
+	}
+	return priced
+}
+
 func GroupBalancesByHexAddr(balances []Balance) map[string][]Balance {
 	grouped := make(map[string][]Balance)
 	for _, balance := range balances {
@@ -40,16 +110,88 @@ func GroupBalancesByHexAddr(balances []Balance) map[string][]Balance {
 	return grouped
 }
 
-func AddFixedBalances(balanceChan chan<- Balance) {
-	for _, balance := range config.GlobalConfig.FixedBalances {
-		usdValue := price.CalculateUSDValue(balance.Token, balance.Amount)
+// KeyGroup is the exposure of one underlying key across every chain it was
+// found on.
+type KeyGroup struct {
+	HexAddr  string       `json:"hex_addr"`
+	Accounts []string     `json:"accounts"`
+	Networks []string     `json:"networks"`
+	USDValue utils.Amount `json:"usd_value"`
+	Share    float64      `json:"share_pct"`
+}
+
+// GroupByKey totals balances by HexAddr, largest first. Balances without a
+// key, such as fixed balances, are left out.
+func GroupByKey(balances []Balance) []KeyGroup {
+	var total utils.Amount
+	for _, b := range balances {
+		total = total.Add(b.USDValue)
+	}
+
+	var groups []KeyGroup
+	for hexAddr, grouped := range GroupBalancesByHexAddr(balances) {
+		if hexAddr == "" {
+			continue
+		}
+
+		group := KeyGroup{HexAddr: hexAddr}
+		accounts := make(map[string]bool)
+		networks := make(map[string]bool)
+		for _, b := range grouped {
+			group.USDValue = group.USDValue.Add(b.USDValue)
+			accounts[b.AccountName()] = true
+			networks[strings.Split(b.Network, "-")[0]] = true
+		}
+		group.Accounts = sortedKeys(accounts)
+		group.Networks = sortedKeys(networks)
+		group.Share = share(group.USDValue, total)
+		groups = append(groups, group)
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		if c := groups[i].USDValue.Cmp(groups[j].USDValue); c != 0 {
+			return c > 0
+		}
+		return groups[i].HexAddr < groups[j].HexAddr
+	})
+	return groups
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func AddFixedBalances(fixed []config.FixedBalance, balanceChan chan<- Balance) {
+	for _, balance := range fixed {
 		balanceChan <- Balance{
 			Network:  balance.Label,
 			Account:  balance.Label,
 			Token:    balance.Token,
 			Amount:   balance.Amount,
-			USDValue: usdValue,
 			Decimals: 1,
+			PriceID:  balance.CoinGeckoID,
+			Owner:    balance.Owner,
+			Tags:     balance.Tags,
+		}
+	}
+}
+
+// LabelBalances copies the label, owner and tags of the configured address
+// entries onto the balances of those accounts. accounts is keyed by the
+// lowercased account as it appears on the balance.
+func LabelBalances(balances []Balance, accounts map[string]config.Address) {
+	for i := range balances {
+		entry, ok := accounts[strings.ToLower(balances[i].Account)]
+		if !ok {
+			continue
 		}
+		balances[i].Label = entry.Label
+		balances[i].Owner = entry.Owner
+		balances[i].Tags = entry.Tags
 	}
 }
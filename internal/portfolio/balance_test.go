@@ -0,0 +1,38 @@
+package portfolio
+
+import (
+	"testing"
+
+	"github.com/anilcse/cosmoscope/pkg/utils"
+)
+
+func TestGroupByKey(t *testing.T) {
+	const key = "40fd27a96cdbffc90ab3b83bf695911426a69fd5"
+	balances := []Balance{
+		{Account: "evmos1abc", Network: "evmos-staking", HexAddr: key, USDValue: utils.ParseAmount("30", 0)},
+		{Account: "inj1abc", Network: "injective-bank", HexAddr: key, USDValue: utils.ParseAmount("20", 0)},
+		{Account: "0x40FD27A96CDBffC90ab3b83bF695911426A69fD5", Label: "Ops", Network: "ethereum", HexAddr: key, USDValue: utils.ParseAmount("50", 0)},
+		{Account: "cosmos1other", Network: "cosmoshub-bank", HexAddr: "01", USDValue: utils.ParseAmount("100", 0)},
+		{Account: "Cold Wallet", Network: "Cold Wallet", USDValue: utils.ParseAmount("800", 0)},
+	}
+
+	groups := GroupByKey(balances)
+	if len(groups) != 2 {
+		t.Fatalf("len(GroupByKey()) = %d, want 2", len(groups))
+	}
+
+	got := groups[0]
+	if got.HexAddr != "01" {
+		t.Errorf("largest key = %v, want 01", got.HexAddr)
+	}
+	got = groups[1]
+	if got.USDValue.String() != "100" || got.Share != 10 {
+		t.Errorf("shared key value = %v (%v%%), want 100 (10%%)", got.USDValue, got.Share)
+	}
+	if len(got.Networks) != 3 || got.Networks[0] != "ethereum" {
+		t.Errorf("shared key networks = %v", got.Networks)
+	}
+	if len(got.Accounts) != 3 || got.Accounts[0] != "Ops" {
+		t.Errorf("shared key accounts = %v", got.Accounts)
+	}
+}
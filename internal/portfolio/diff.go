@@ -0,0 +1,325 @@
+package portfolio
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/anilcse/cosmoscope/pkg/utils"
+	"github.com/olekukonko/tablewriter"
+)
+
+// Change kinds reported by DiffSnapshots.
+const (
+	ChangeDeposit       = "Deposit"
+	ChangeWithdrawal    = "Withdrawal"
+	ChangeRewardAccrual = "Reward accrual"
+	ChangeRewardClaim   = "Reward claimed"
+	ChangePriceOnly     = "Price only"
+	// ChangeTransfer is a token moving between categories of one account
+	// on one chain, such as claimed rewards reaching the bank balance or
+	// stake starting to unbond.
+	ChangeTransfer = "Transfer"
+)
+
+// Change describes how one (Account, Network, Token) position moved between
+// two snapshots. The value change is split into a quantity effect, valued at
+// the later price, and a price effect on the earlier quantity; the two add
+// up to the total value change.
+type Change struct {
+	Account        string
+	Network        string
+	Token          string
+	Kind           string
+	AmountBefore   utils.Amount
+	AmountAfter    utils.Amount
+	ValueBefore    utils.Amount
+	ValueAfter     utils.Amount
+	QuantityEffect utils.Amount
+	PriceEffect    utils.Amount
+	// Transferred is the part of the amount change offset by an opposite
+	// change of the same token in another category of the account on the
+	// same chain, and TransferEffect is its share of QuantityEffect.
+	Transferred    utils.Amount
+	TransferEffect utils.Amount
+
+	priceAfter utils.Amount
+}
+
+func (c Change) AmountChange() utils.Amount {
+	return c.AmountAfter.Sub(c.AmountBefore)
+}
+
+func (c Change) ValueChange() utils.Amount {
+	return c.ValueAfter.Sub(c.ValueBefore)
+}
+
+type balanceKey struct {
+	account string
+	network string
+	token   string
+}
+
+type position struct {
+	amount utils.Amount
+	value  utils.Amount
+	price  utils.Amount
+	sample Balance
+}
+
+// DiffSnapshots compares two snapshots position by position. Positions
+// whose amount and value are both unchanged are omitted.
+func DiffSnapshots(before, after Snapshot) []Change {
+	beforePositions := positions(before)
+	afterPositions := positions(after)
+
+	keys := make(map[balanceKey]bool)
+	for key := range beforePositions {
+		keys[key] = true
+	}
+	for key := range afterPositions {
+		keys[key] = true
+	}
+
+	var changes []Change
+	for key := range keys {
+		a, inBefore := beforePositions[key]
+		b, inAfter := afterPositions[key]
+
+		// A position that only exists on one side is valued at the price
+		// of the side that has it.
+		priceBefore, priceAfter := a.price, b.price
+		if !inBefore {
+			priceBefore = priceAfter
+		}
+		if !inAfter {
+			priceAfter = priceBefore
+		}
+
+		change := Change{
+			Account:        key.account,
+			Network:        key.network,
+			Token:          key.token,
+			AmountBefore:   a.amount,
+			AmountAfter:    b.amount,
+			ValueBefore:    a.value,
+			ValueAfter:     b.value,
+			QuantityEffect: b.amount.Sub(a.amount).Mul(priceAfter),
+			PriceEffect:    a.amount.Mul(priceAfter.Sub(priceBefore)),
+			priceAfter:     priceAfter,
+		}
+
+		sample := b.sample
+		if !inAfter {
+			sample = a.sample
+		}
+		change.Kind = changeKind(sample, change.AmountChange())
+
+		if change.AmountChange().IsZero() && change.ValueChange().IsZero() {
+			continue
+		}
+		changes = append(changes, change)
+	}
+	matchTransfers(changes)
+
+	sort.Slice(changes, func(i, j int) bool {
+		vi, vj := changes[i].ValueChange(), changes[j].ValueChange()
+		if vi.Sign() < 0 {
+			vi = vi.Neg()
+		}
+		if vj.Sign() < 0 {
+			vj = vj.Neg()
+		}
+		return vi.Cmp(vj) > 0
+	})
+	return changes
+}
+
+// matchTransfers marks the part of every amount change that is offset by an
+// opposite change of the same token in another category of the same account
+// and chain. Equal and opposite changes are paired first, the rest in
+// network order; a change that is offset completely becomes a
+// ChangeTransfer.
+func matchTransfers(changes []Change) {
+	groups := make(map[balanceKey][]int)
+	for i, c := range changes {
+		key := balanceKey{account: c.Account, network: strings.Split(c.Network, "-")[0], token: c.Token}
+		groups[key] = append(groups[key], i)
+	}
+
+	for _, indexes := range groups {
+		sort.Slice(indexes, func(i, j int) bool {
+			return changes[indexes[i]].Network < changes[indexes[j]].Network
+		})
+
+		// unmatched holds the part of each amount change not yet paired.
+		unmatched := make([]utils.Amount, len(indexes))
+		for k, i := range indexes {
+			unmatched[k] = changes[i].AmountChange()
+		}
+		pair := func(exact bool) {
+			for in := range unmatched {
+				for out := range unmatched {
+					if unmatched[in].Sign() <= 0 || unmatched[out].Sign() >= 0 {
+						continue
+					}
+					if exact && unmatched[in].Cmp(unmatched[out].Neg()) != 0 {
+						continue
+					}
+					moved := minAmount(unmatched[in], unmatched[out].Neg())
+					unmatched[in] = unmatched[in].Sub(moved)
+					unmatched[out] = unmatched[out].Add(moved)
+				}
+			}
+		}
+		pair(true)
+		pair(false)
+
+		for k, i := range indexes {
+			c := &changes[i]
+			transferred := c.AmountChange().Sub(unmatched[k])
+			if transferred.IsZero() {
+				continue
+			}
+			c.Transferred = transferred
+			c.TransferEffect = transferred.Mul(c.priceAfter)
+			if unmatched[k].IsZero() {
+				c.Kind = ChangeTransfer
+			}
+		}
+	}
+}
+
+func minAmount(a, b utils.Amount) utils.Amount {
+	if a.Cmp(b) < 0 {
+		return a
+	}
+	return b
+}
+
+func positions(snapshot Snapshot) map[balanceKey]position {
+	result := make(map[balanceKey]position)
+	for _, b := range snapshot.Balances {
+		key := balanceKey{account: b.Account, network: b.Network, token: b.Token}
+		p := result[key]
+		p.amount = p.amount.Add(b.Amount)
+		p.value = p.value.Add(b.USDValue)
+		p.price = snapshotPrice(snapshot, b)
+		p.sample = b
+		result[key] = p
+	}
+	return result
+}
+
+// snapshotPrice returns the unit price stored with the snapshot for b.
+func snapshotPrice(snapshot Snapshot, b Balance) utils.Amount {
+	if p, ok := snapshot.Prices[b.priceToken().Key()]; ok {
+		return utils.AmountFromFloat(p)
+	}
+	return utils.Amount{}
+}
+
+func changeKind(b Balance, amountChange utils.Amount) string {
+	isReward := false
+	switch getAssetType(b) {
+	case "Rewards", "Commission":
+		isReward = true
+	}
+
+	switch {
+	case amountChange.Sign() > 0 && isReward:
+		return ChangeRewardAccrual
+	case amountChange.Sign() > 0:
+		return ChangeDeposit
+	case amountChange.Sign() < 0 && isReward:
+		return ChangeRewardClaim
+	case amountChange.Sign() < 0:
+		return ChangeWithdrawal
+	default:
+		return ChangePriceOnly
+	}
+}
+
+// PrintDiff prints every changed position followed by a summary that
+// separates price-driven from quantity-driven value change.
+func PrintDiff(before, after Snapshot) {
+	changes := DiffSnapshots(before, after)
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Account", "Network", "Token", "Kind", "Amount Change", "Quantity Effect", "Price Effect", "Value Change"})
+	table.SetAutoMergeCells(false)
+	table.SetRowLine(true)
+
+	// Set all headers to bold
+	table.SetHeaderColor(
+		tablewriter.Colors{tablewriter.Bold},
+		tablewriter.Colors{tablewriter.Bold},
+		tablewriter.Colors{tablewriter.Bold},
+		tablewriter.Colors{tablewriter.Bold},
+		tablewriter.Colors{tablewriter.Bold},
+		tablewriter.Colors{tablewriter.Bold},
+		tablewriter.Colors{tablewriter.Bold},
+		tablewriter.Colors{tablewriter.Bold},
+	)
+
+	totals := make(map[string]utils.Amount)
+	var quantityEffect, priceEffect utils.Amount
+	for _, c := range changes {
+		// Only the part of a change that was not transferred counts as a
+		// deposit, withdrawal or reward.
+		totals[c.Kind] = totals[c.Kind].Add(c.QuantityEffect.Sub(c.TransferEffect))
+		totals[ChangeTransfer] = totals[ChangeTransfer].Add(c.TransferEffect)
+		quantityEffect = quantityEffect.Add(c.QuantityEffect)
+		priceEffect = priceEffect.Add(c.PriceEffect)
+
+		color := tablewriter.Colors{}
+		if c.ValueChange().Sign() < 0 {
+			color = tablewriter.Colors{tablewriter.FgRedColor}
+		} else if c.ValueChange().Sign() > 0 {
+			color = tablewriter.Colors{tablewriter.FgGreenColor}
+		}
+
+		table.Rich([]string{
+			truncateString(c.Account, 20),
+			c.Network,
+			c.Token,
+			c.Kind,
+			fmt.Sprintf("%+.4f", c.AmountChange().Float64()),
+			fmt.Sprintf("%+.2f", c.QuantityEffect.Float64()),
+			fmt.Sprintf("%+.2f", c.PriceEffect.Float64()),
+			fmt.Sprintf("%+.2f", c.ValueChange().Float64()),
+		}, []tablewriter.Colors{{}, {}, {}, {}, {}, {}, {}, color})
+	}
+
+	titleColor.Printf("Changes from %s to %s:\n", before.ID(), after.ID())
+	table.Render()
+	fmt.Println()
+
+	summary := tablewriter.NewWriter(os.Stdout)
+	summary.SetHeader([]string{"Component", "USD Value"})
+	summary.SetAutoMergeCells(false)
+	summary.SetRowLine(true)
+	summary.SetHeaderColor(
+		tablewriter.Colors{tablewriter.Bold},
+		tablewriter.Colors{tablewriter.Bold},
+	)
+
+	beforeTotal, afterTotal := before.TotalValue(), after.TotalValue()
+	rows := [][]string{
+		{"Value at " + before.ID(), fmt.Sprintf("$%.2f", beforeTotal.Float64())},
+		{"Deposits", fmt.Sprintf("%+.2f", totals[ChangeDeposit].Float64())},
+		{"Withdrawals", fmt.Sprintf("%+.2f", totals[ChangeWithdrawal].Float64())},
+		{"Reward accrual", fmt.Sprintf("%+.2f", totals[ChangeRewardAccrual].Float64())},
+		{"Rewards claimed", fmt.Sprintf("%+.2f", totals[ChangeRewardClaim].Float64())},
+		{"Transfers between categories", fmt.Sprintf("%+.2f", totals[ChangeTransfer].Float64())},
+		{"Quantity-driven change", fmt.Sprintf("%+.2f", quantityEffect.Float64())},
+		{"Price-driven change", fmt.Sprintf("%+.2f", priceEffect.Float64())},
+		{"Value at " + after.ID(), fmt.Sprintf("$%.2f", afterTotal.Float64())},
+		{"Total change", fmt.Sprintf("%+.2f", afterTotal.Sub(beforeTotal).Float64())},
+	}
+	summary.AppendBulk(rows)
+
+	titleColor.Println("Change Summary:")
+	summary.Render()
+}
@@ -0,0 +1,124 @@
+package portfolio
+
+import (
+	"testing"
+
+	"github.com/anilcse/cosmoscope/pkg/utils"
+)
+
+func TestDiffSnapshots(t *testing.T) {
+	balance := func(network, amount, value string) Balance {
+		return Balance{
+			Account:  "cosmos1abc",
+			Network:  network,
+			Token:    "ATOM",
+			PriceID:  "cosmos",
+			Amount:   utils.ParseAmount(amount, 0),
+			USDValue: utils.ParseAmount(value, 0),
+		}
+	}
+
+	before := Snapshot{
+		Balances: []Balance{
+			balance("cosmoshub-bank", "100", "1000"),
+			balance("cosmoshub-rewards", "1", "10"),
+		},
+		Prices: map[string]float64{"id:cosmos": 10},
+	}
+	after := Snapshot{
+		Balances: []Balance{
+			balance("cosmoshub-bank", "120", "1440"),
+			balance("cosmoshub-rewards", "2", "24"),
+		},
+		Prices: map[string]float64{"id:cosmos": 12},
+	}
+
+	changes := DiffSnapshots(before, after)
+	if len(changes) != 2 {
+		t.Fatalf("len(DiffSnapshots()) = %d, want 2", len(changes))
+	}
+
+	bank := changes[0]
+	if bank.Kind != ChangeDeposit {
+		t.Errorf("bank kind = %v, want %v", bank.Kind, ChangeDeposit)
+	}
+	if got := bank.QuantityEffect.String(); got != "240" {
+		t.Errorf("quantity effect = %v, want 240", got)
+	}
+	if got := bank.PriceEffect.String(); got != "200" {
+		t.Errorf("price effect = %v, want 200", got)
+	}
+	if got := bank.QuantityEffect.Add(bank.PriceEffect); got.Cmp(bank.ValueChange()) != 0 {
+		t.Errorf("effects sum to %v, want value change %v", got, bank.ValueChange())
+	}
+
+	if rewards := changes[1]; rewards.Kind != ChangeRewardAccrual {
+		t.Errorf("rewards kind = %v, want %v", rewards.Kind, ChangeRewardAccrual)
+	}
+}
+
+func TestDiffSnapshotsTransfers(t *testing.T) {
+	balance := func(account, network, amount string) Balance {
+		return Balance{
+			Account:  account,
+			Network:  network,
+			Token:    "ATOM",
+			PriceID:  "cosmos",
+			Amount:   utils.ParseAmount(amount, 0),
+			USDValue: utils.ParseAmount(amount, 0).Mul(utils.ParseAmount("10", 0)),
+		}
+	}
+	prices := map[string]float64{"id:cosmos": 10}
+
+	before := Snapshot{
+		Balances: []Balance{
+			balance("cosmos1abc", "cosmoshub-bank", "100"),
+			balance("cosmos1abc", "cosmoshub-rewards", "5"),
+			balance("cosmos1abc", "cosmoshub-staking", "50"),
+			balance("cosmos1def", "cosmoshub-bank", "10"),
+		},
+		Prices: prices,
+	}
+	after := Snapshot{
+		Balances: []Balance{
+			// Claimed the 5 ATOM of rewards and received 3 more.
+			balance("cosmos1abc", "cosmoshub-bank", "108"),
+			// 20 ATOM started to unbond.
+			balance("cosmos1abc", "cosmoshub-staking", "30"),
+			balance("cosmos1abc", "cosmoshub-unbonding", "20"),
+			// A different account is not part of the transfer.
+			balance("cosmos1def", "cosmoshub-bank", "5"),
+		},
+		Prices: prices,
+	}
+
+	kinds := make(map[string]Change)
+	for _, c := range DiffSnapshots(before, after) {
+		kinds[c.Account+" "+c.Network] = c
+	}
+
+	tests := []struct {
+		position        string
+		wantKind        string
+		wantTransferred string
+	}{
+		{"cosmos1abc cosmoshub-bank", ChangeDeposit, "5"},
+		{"cosmos1abc cosmoshub-rewards", ChangeTransfer, "-5"},
+		{"cosmos1abc cosmoshub-staking", ChangeTransfer, "-20"},
+		{"cosmos1abc cosmoshub-unbonding", ChangeTransfer, "20"},
+		{"cosmos1def cosmoshub-bank", ChangeWithdrawal, "0"},
+	}
+	for _, tt := range tests {
+		c, ok := kinds[tt.position]
+		if !ok {
+			t.Errorf("%s: no change reported", tt.position)
+			continue
+		}
+		if c.Kind != tt.wantKind || c.Transferred.String() != tt.wantTransferred {
+			t.Errorf("%s = %s with %s transferred, want %s with %s", tt.position, c.Kind, c.Transferred, tt.wantKind, tt.wantTransferred)
+		}
+	}
+	if got := kinds["cosmos1abc cosmoshub-bank"].TransferEffect.String(); got != "50" {
+		t.Errorf("bank transfer effect = %s, want 50", got)
+	}
+}
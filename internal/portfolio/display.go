@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/anilcse/cosmoscope/pkg/utils"
 	"github.com/fatih/color"
 	"github.com/olekukonko/tablewriter"
 )
@@ -19,18 +20,26 @@ var (
 	totalValueColor = color.New(color.FgGreen, color.Bold) // For timestamp
 )
 
-var totalValue float64
+var totalValue utils.Amount
 
 var tokens = make(map[string]*struct {
-	amount   float64
-	usdValue float64
+	amount   utils.Amount
+	usdValue utils.Amount
 })
 
-func PrintBalanceReport(balances []Balance) {
+// PrintBalanceReport prints every section of the report, followed by the
+// tokens that were filtered out as spam.
+func PrintBalanceReport(balances []Balance, filtered []FilteredToken) {
 	printDetailedView(balances)
 	printPortfolioSummary(balances)
 	printNetworkDistribution(balances)
 	printAssetTypes(balances)
+	printOwnerDistribution(balances)
+	printTagDistribution(balances)
+	printKeyGroups(balances)
+	printUnbondingSchedule(balances)
+	printVestingSchedule(balances)
+	printFilteredTokens(filtered)
 	PrintFooter(balances)
 }
 
@@ -48,23 +57,23 @@ func PrintHeader() {
 }
 
 func PrintFooter(balances []Balance) {
-	totalValue = 0
+	totalValue = utils.Amount{}
 	for _, b := range balances {
 		if _, exists := tokens[b.Token]; !exists {
 			tokens[b.Token] = &struct {
-				amount   float64
-				usdValue float64
+				amount   utils.Amount
+				usdValue utils.Amount
 			}{}
 		}
 
-		totalValue += b.USDValue
+		totalValue = totalValue.Add(b.USDValue)
 	}
 
 	headerColor.Println("\n╔════════════════════════════════════════════════════════════╗")
 	headerColor.Printf("║ %s", strings.Repeat(" ", 59))
 	headerColor.Println("║")
 	headerColor.Printf("║              Total USD value - ")
-	timeColor.Printf("$%.2f", totalValue)
+	timeColor.Printf("$%.2f", totalValue.Float64())
 	headerColor.Printf("                 ║\n")
 	headerColor.Printf("║ %s", strings.Repeat(" ", 59))
 	headerColor.Println("║")
@@ -75,11 +84,11 @@ func PrintFooter(balances []Balance) {
 func printDetailedView(balances []Balance) {
 	// Sort balances by USDValue descending
 	sort.Slice(balances, func(i, j int) bool {
-		return balances[i].USDValue > balances[j].USDValue
+		return balances[i].USDValue.Cmp(balances[j].USDValue) > 0
 	})
 
 	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Account", "Network", "Token", "Amount", "USD Value"})
+	table.SetHeader([]string{"Account", "Network", "Token", "Amount", "USD Value", "Price Source"})
 	table.SetAutoMergeCells(false)
 	table.SetRowLine(true)
 
@@ -90,27 +99,30 @@ func printDetailedView(balances []Balance) {
 		tablewriter.Colors{tablewriter.Bold},
 		tablewriter.Colors{tablewriter.Bold},
 		tablewriter.Colors{tablewriter.Bold},
+		tablewriter.Colors{tablewriter.Bold},
 	)
 
 	// Determine min and max USDValue for gradient
 	var minUSD, maxUSD float64
 	if len(balances) > 0 {
-		minUSD, maxUSD = balances[len(balances)-1].USDValue, balances[0].USDValue
+		minUSD, maxUSD = balances[len(balances)-1].USDValue.Float64(), balances[0].USDValue.Float64()
 	}
 
 	for _, b := range balances {
+		usdValue := b.USDValue.Float64()
 		row := []string{
-			truncateString(b.Account, 20),
+			truncateString(b.AccountName(), 20),
 			b.Network,
 			b.Token,
-			fmt.Sprintf("%.4f", b.Amount),
-			fmt.Sprintf("$%.2f", b.USDValue),
+			fmt.Sprintf("%.4f", b.Amount.Float64()),
+			fmt.Sprintf("$%.2f", usdValue),
+			b.PriceSource,
 		}
 
 		// Calculate normalized value (0 = min, 1 = max)
 		norm := 0.0
 		if maxUSD > minUSD {
-			norm = (b.USDValue - minUSD) / (maxUSD - minUSD)
+			norm = (usdValue - minUSD) / (maxUSD - minUSD)
 		}
 
 		// Assign color: top 20% bold green, next 30% normal green, rest no color
@@ -123,7 +135,7 @@ func printDetailedView(balances []Balance) {
 			color = tablewriter.Colors{} // default
 		}
 
-		table.Rich(row, []tablewriter.Colors{color, color, color, color, color})
+		table.Rich(row, []tablewriter.Colors{color, color, color, color, color, color})
 	}
 
 	titleColor.Println("Detailed Balance View:")
@@ -133,20 +145,20 @@ func printDetailedView(balances []Balance) {
 
 func printPortfolioSummary(balances []Balance) {
 	tokens = make(map[string]*struct {
-		amount   float64
-		usdValue float64
+		amount   utils.Amount
+		usdValue utils.Amount
 	})
-	totalValue = 0
+	totalValue = utils.Amount{}
 	for _, b := range balances {
 		if _, exists := tokens[b.Token]; !exists {
 			tokens[b.Token] = &struct {
-				amount   float64
-				usdValue float64
+				amount   utils.Amount
+				usdValue utils.Amount
 			}{}
 		}
-		tokens[b.Token].amount += b.Amount
-		tokens[b.Token].usdValue += b.USDValue
-		totalValue += b.USDValue
+		tokens[b.Token].amount = tokens[b.Token].amount.Add(b.Amount)
+		tokens[b.Token].usdValue = tokens[b.Token].usdValue.Add(b.USDValue)
+		totalValue = totalValue.Add(b.USDValue)
 	}
 
 	// Collect token summaries for sorting
@@ -159,8 +171,8 @@ func printPortfolioSummary(balances []Balance) {
 	for token, sum := range tokens {
 		rows = append(rows, tokenRow{
 			token:    token,
-			amount:   sum.amount,
-			usdValue: sum.usdValue,
+			amount:   sum.amount.Float64(),
+			usdValue: sum.usdValue.Float64(),
 		})
 	}
 
@@ -189,7 +201,7 @@ func printPortfolioSummary(balances []Balance) {
 	)
 
 	for _, row := range rows {
-		share := (row.usdValue / totalValue) * 100
+		share := (row.usdValue / totalValue.Float64()) * 100
 		rowData := []string{
 			row.token,
 			fmt.Sprintf("%.4f", row.amount),
@@ -223,17 +235,17 @@ func printPortfolioSummary(balances []Balance) {
 	titleColor.Println("Portfolio Summary:")
 	table.Render()
 	fmt.Printf("Total Portfolio Value: ")
-	totalValueColor.Printf("$%.2f\n\n", totalValue)
+	totalValueColor.Printf("$%.2f\n\n", totalValue.Float64())
 }
 
 func printNetworkDistribution(balances []Balance) {
-	networks := make(map[string]float64)
-	var totalValue float64
+	networks := make(map[string]utils.Amount)
+	var totalValue utils.Amount
 
 	for _, b := range balances {
 		network := strings.Split(b.Network, "-")[0]
-		networks[network] += b.USDValue
-		totalValue += b.USDValue
+		networks[network] = networks[network].Add(b.USDValue)
+		totalValue = totalValue.Add(b.USDValue)
 	}
 
 	table := tablewriter.NewWriter(os.Stdout)
@@ -249,10 +261,10 @@ func printNetworkDistribution(balances []Balance) {
 	)
 
 	for network, value := range networks {
-		share := (value / totalValue) * 100
+		share := (value.Float64() / totalValue.Float64()) * 100
 		table.Append([]string{
 			network,
-			fmt.Sprintf("$%.2f", value),
+			fmt.Sprintf("$%.2f", value.Float64()),
 			fmt.Sprintf("%.2f%%", share),
 		})
 	}
@@ -263,20 +275,13 @@ func printNetworkDistribution(balances []Balance) {
 }
 
 func printAssetTypes(balances []Balance) {
-	types := make(map[string]float64)
-	var totalValue float64
+	types := make(map[string]utils.Amount)
+	var totalValue utils.Amount
 
 	for _, b := range balances {
-		assetType := "Bank"
-		if strings.Contains(b.Network, "staking") {
-			assetType = "Staking"
-		} else if strings.Contains(b.Network, "rewards") {
-			assetType = "Rewards"
-		} else if strings.Contains(b.Network, "Fixed") {
-			assetType = "Fixed"
-		}
-		types[assetType] += b.USDValue
-		totalValue += b.USDValue
+		assetType := getAssetType(b)
+		types[assetType] = types[assetType].Add(b.USDValue)
+		totalValue = totalValue.Add(b.USDValue)
 	}
 
 	table := tablewriter.NewWriter(os.Stdout)
@@ -292,10 +297,10 @@ func printAssetTypes(balances []Balance) {
 	)
 
 	for assetType, value := range types {
-		share := (value / totalValue) * 100
+		share := (value.Float64() / totalValue.Float64()) * 100
 		table.Append([]string{
 			assetType,
-			fmt.Sprintf("$%.2f", value),
+			fmt.Sprintf("$%.2f", value.Float64()),
 			fmt.Sprintf("%.2f%%", share),
 		})
 	}
@@ -304,6 +309,284 @@ func printAssetTypes(balances []Balance) {
 	table.Render()
 }
 
+// printOwnerDistribution groups value by the owner of each account. It is
+// only shown when at least one account has an owner.
+func printOwnerDistribution(balances []Balance) {
+	owners := ownerDistribution(balances)
+	if len(owners) == 0 {
+		return
+	}
+	printDistribution("Owner Distribution:", "Owner", owners)
+}
+
+// printTagDistribution shows the value of the accounts carrying each tag.
+// An account may carry several tags, so shares can add up to more than 100%.
+func printTagDistribution(balances []Balance) {
+	tags := tagDistribution(balances)
+	if len(tags) == 0 {
+		return
+	}
+	printDistribution("Tags:", "Tag", tags)
+}
+
+func printDistribution(title, name string, rows []Distribution) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{name, "USD Value", "Share %"})
+	table.SetAutoMergeCells(false)
+	table.SetRowLine(true)
+
+	// Set all headers to bold
+	table.SetHeaderColor(
+		tablewriter.Colors{tablewriter.Bold},
+		tablewriter.Colors{tablewriter.Bold},
+		tablewriter.Colors{tablewriter.Bold},
+	)
+
+	for _, row := range rows {
+		table.Append([]string{
+			row.Name,
+			fmt.Sprintf("$%.2f", row.USDValue.Float64()),
+			fmt.Sprintf("%.2f%%", row.Share),
+		})
+	}
+
+	fmt.Println()
+	titleColor.Println(title)
+	table.Render()
+}
+
+// printKeyGroups shows the total exposure of each underlying key: the same
+// key is queried under a different bech32 prefix on every Cosmos chain, and
+// on Ethermint chains its address bytes are also the EVM address.
+func printKeyGroups(balances []Balance) {
+	groups := GroupByKey(balances)
+	if len(groups) == 0 {
+		return
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Key", "Accounts", "Networks", "USD Value", "Share %"})
+	table.SetAutoMergeCells(false)
+	table.SetRowLine(true)
+
+	// Set all headers to bold
+	table.SetHeaderColor(
+		tablewriter.Colors{tablewriter.Bold},
+		tablewriter.Colors{tablewriter.Bold},
+		tablewriter.Colors{tablewriter.Bold},
+		tablewriter.Colors{tablewriter.Bold},
+		tablewriter.Colors{tablewriter.Bold},
+	)
+
+	for _, group := range groups {
+		var accounts []string
+		for _, account := range group.Accounts {
+			accounts = append(accounts, truncateString(account, 20))
+		}
+		table.Append([]string{
+			utils.ShortenAddress("0x" + group.HexAddr),
+			strings.Join(accounts, "\n"),
+			strings.Join(group.Networks, ", "),
+			fmt.Sprintf("$%.2f", group.USDValue.Float64()),
+			fmt.Sprintf("%.2f%%", group.Share),
+		})
+	}
+
+	fmt.Println()
+	titleColor.Println("Exposure by Key:")
+	table.Render()
+}
+
+// printFilteredTokens lists the tokens a spam policy left out of the
+// report and why.
+func printFilteredTokens(tokens []FilteredToken) {
+	if len(tokens) == 0 {
+		return
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Network", "Account", "Token", "Contract", "Reason"})
+	table.SetAutoMergeCells(false)
+	table.SetRowLine(true)
+
+	// Set all headers to bold
+	table.SetHeaderColor(
+		tablewriter.Colors{tablewriter.Bold},
+		tablewriter.Colors{tablewriter.Bold},
+		tablewriter.Colors{tablewriter.Bold},
+		tablewriter.Colors{tablewriter.Bold},
+		tablewriter.Colors{tablewriter.Bold},
+	)
+
+	for _, token := range tokens {
+		table.Append([]string{
+			token.Network,
+			utils.ShortenAddress(token.Account),
+			truncateString(token.Symbol, 20),
+			utils.ShortenAddress(token.Contract),
+			token.Reason,
+		})
+	}
+
+	fmt.Println()
+	titleColor.Println("Filtered Tokens:")
+	table.Render()
+}
+
+// AssetType labels the kind of balance: Bank, Staking, Rewards and so on.
+func AssetType(b Balance) string {
+	return getAssetType(b)
+}
+
+func getAssetType(b Balance) string {
+	switch {
+	case strings.Contains(b.Network, "staking"):
+		return "Staking"
+	case strings.Contains(b.Network, "selfbond"):
+		return "Self-Bond"
+	case strings.Contains(b.Network, "commission"):
+		return "Commission"
+	case strings.Contains(b.Network, "unbonding"):
+		return "Unbonding"
+	case strings.Contains(b.Network, "redelegating"):
+		return "Redelegating"
+	case strings.Contains(b.Network, "vesting"):
+		return "Vesting (Locked)"
+	case strings.Contains(b.Network, "rewards"):
+		return "Rewards"
+	case strings.Contains(b.Network, "Fixed"):
+		return "Fixed"
+	default:
+		return "Bank"
+	}
+}
+
+// printUnbondingSchedule lists every time-locked tranche in the order it
+// completes, so it is clear when unbonding tokens become liquid again.
+func printUnbondingSchedule(balances []Balance) {
+	var scheduled []Balance
+	for _, b := range balances {
+		if b.CompletionTime != nil {
+			scheduled = append(scheduled, b)
+		}
+	}
+	if len(scheduled) == 0 {
+		return
+	}
+
+	sort.Slice(scheduled, func(i, j int) bool {
+		return scheduled[i].CompletionTime.Before(*scheduled[j].CompletionTime)
+	})
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Completes", "In", "Type", "Account", "Network", "Token", "Amount", "USD Value"})
+	table.SetAutoMergeCells(false)
+	table.SetRowLine(true)
+
+	// Set all headers to bold
+	table.SetHeaderColor(
+		tablewriter.Colors{tablewriter.Bold},
+		tablewriter.Colors{tablewriter.Bold},
+		tablewriter.Colors{tablewriter.Bold},
+		tablewriter.Colors{tablewriter.Bold},
+		tablewriter.Colors{tablewriter.Bold},
+		tablewriter.Colors{tablewriter.Bold},
+		tablewriter.Colors{tablewriter.Bold},
+		tablewriter.Colors{tablewriter.Bold},
+	)
+
+	now := time.Now()
+	for _, b := range scheduled {
+		table.Append([]string{
+			b.CompletionTime.Local().Format("2006-01-02 15:04"),
+			formatRemaining(b.CompletionTime.Sub(now)),
+			getAssetType(b),
+			truncateString(b.AccountName(), 20),
+			strings.Split(b.Network, "-")[0],
+			b.Token,
+			fmt.Sprintf("%.4f", b.Amount.Float64()),
+			fmt.Sprintf("$%.2f", b.USDValue.Float64()),
+		})
+	}
+
+	fmt.Println()
+	titleColor.Println("Unbonding Schedule:")
+	table.Render()
+}
+
+// printVestingSchedule projects when locked vesting balances are released.
+// USD values use the current price of each token.
+func printVestingSchedule(balances []Balance) {
+	type unlockRow struct {
+		balance Balance
+		unlock  Unlock
+	}
+	var rows []unlockRow
+	for _, b := range balances {
+		for _, unlock := range b.Unlocks {
+			rows = append(rows, unlockRow{balance: b, unlock: unlock})
+		}
+	}
+	if len(rows) == 0 {
+		return
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].unlock.Time.Before(rows[j].unlock.Time)
+	})
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Unlocks", "In", "Account", "Network", "Token", "Amount", "USD Value"})
+	table.SetAutoMergeCells(false)
+	table.SetRowLine(true)
+
+	// Set all headers to bold
+	table.SetHeaderColor(
+		tablewriter.Colors{tablewriter.Bold},
+		tablewriter.Colors{tablewriter.Bold},
+		tablewriter.Colors{tablewriter.Bold},
+		tablewriter.Colors{tablewriter.Bold},
+		tablewriter.Colors{tablewriter.Bold},
+		tablewriter.Colors{tablewriter.Bold},
+		tablewriter.Colors{tablewriter.Bold},
+	)
+
+	now := time.Now()
+	for _, row := range rows {
+		amount := row.unlock.Amount.Float64()
+		var usdValue float64
+		if total := row.balance.Amount.Float64(); total > 0 {
+			usdValue = amount * row.balance.USDValue.Float64() / total
+		}
+
+		table.Append([]string{
+			row.unlock.Time.Local().Format("2006-01-02"),
+			formatRemaining(row.unlock.Time.Sub(now)),
+			truncateString(row.balance.AccountName(), 20),
+			strings.Split(row.balance.Network, "-")[0],
+			row.balance.Token,
+			fmt.Sprintf("%.4f", amount),
+			fmt.Sprintf("$%.2f", usdValue),
+		})
+	}
+
+	fmt.Println()
+	titleColor.Println("Vesting Schedule:")
+	table.Render()
+}
+
+func formatRemaining(d time.Duration) string {
+	if d <= 0 {
+		return "now"
+	}
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	if days > 0 {
+		return fmt.Sprintf("%dd %dh", days, hours)
+	}
+	return fmt.Sprintf("%dh %dm", hours, int(d.Minutes())%60)
+}
+
 func truncateString(s string, length int) string {
 	if len(s) <= length {
 		return s
@@ -0,0 +1,62 @@
+package portfolio
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anilcse/cosmoscope/pkg/utils"
+)
+
+// captureStdout returns what print writes to os.Stdout.
+func captureStdout(t *testing.T, print func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	print()
+	w.Close()
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func TestPrintUnbondingSchedule(t *testing.T) {
+	later := time.Now().Add(72 * time.Hour)
+	sooner := time.Now().Add(24 * time.Hour)
+	balances := []Balance{
+		{Account: "cosmos1abc", Network: "cosmoshub-bank", Token: "ATOM", Amount: utils.ParseAmount("1", 0)},
+		{Account: "cosmos1abc", Network: "cosmoshub-redelegating", Token: "ATOM", Amount: utils.ParseAmount("2", 0), CompletionTime: &later},
+		{Account: "osmo1abc", Network: "osmosis-unbonding", Token: "OSMO", Amount: utils.ParseAmount("3", 0), CompletionTime: &sooner},
+	}
+
+	out := captureStdout(t, func() { printUnbondingSchedule(balances) })
+
+	osmo := strings.Index(out, "OSMO")
+	atom := strings.Index(out, "ATOM")
+	if osmo < 0 || atom < 0 {
+		t.Fatalf("schedule is missing an entry:\n%s", out)
+	}
+	if osmo > atom {
+		t.Errorf("entries are not ordered by completion time:\n%s", out)
+	}
+	if !strings.Contains(out, "Unbonding") || !strings.Contains(out, "Redelegating") {
+		t.Errorf("schedule is missing the entry types:\n%s", out)
+	}
+	if strings.Contains(out, "1.0000") {
+		t.Errorf("bank balance listed in the schedule:\n%s", out)
+	}
+
+	if out := captureStdout(t, func() { printUnbondingSchedule(balances[:1]) }); out != "" {
+		t.Errorf("printed a schedule without unbonding entries:\n%s", out)
+	}
+}
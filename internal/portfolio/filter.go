@@ -0,0 +1,78 @@
+package portfolio
+
+import (
+	"strings"
+
+	"github.com/anilcse/cosmoscope/pkg/utils"
+)
+
+// Filter selects balances for a report. Empty fields match everything.
+type Filter struct {
+	// Network matches either the chain name or the full network label,
+	// e.g. "osmosis" or "osmosis-staking".
+	Network string
+	// Account matches the address or the label of an account.
+	Account string
+	Token   string
+	Owner   string
+	Tag     string
+	MinUSD  utils.Amount
+}
+
+func (f Filter) IsZero() bool {
+	return f.Network == "" && f.Account == "" && f.Token == "" && f.Owner == "" && f.Tag == "" && f.MinUSD.IsZero()
+}
+
+// MatchesNetwork reports whether a chain queried under name can produce
+// balances that pass the network filter.
+func (f Filter) MatchesNetwork(name string) bool {
+	return f.Network == "" || strings.EqualFold(name, f.Network) || strings.EqualFold(name, strings.Split(f.Network, "-")[0])
+}
+
+// MatchesNetwork reports whether b is on network, given either as the full
+// network name ("cosmoshub-staking") or as the chain name ("cosmoshub").
+func MatchesNetwork(b Balance, network string) bool {
+	return strings.EqualFold(b.Network, network) || strings.EqualFold(strings.Split(b.Network, "-")[0], network)
+}
+
+func (f Filter) Match(b Balance) bool {
+	if f.Network != "" && !MatchesNetwork(b, f.Network) {
+		return false
+	}
+	if f.Account != "" && !strings.EqualFold(b.Account, f.Account) && !strings.EqualFold(b.Label, f.Account) {
+		return false
+	}
+	if f.Owner != "" && !strings.EqualFold(b.Owner, f.Owner) {
+		return false
+	}
+	if f.Tag != "" && !hasTag(b, f.Tag) {
+		return false
+	}
+	if f.Token != "" && !strings.EqualFold(b.Token, f.Token) {
+		return false
+	}
+	return b.USDValue.Cmp(f.MinUSD) >= 0
+}
+
+func hasTag(b Balance, tag string) bool {
+	for _, t := range b.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func FilterBalances(balances []Balance, f Filter) []Balance {
+	if f.IsZero() {
+		return balances
+	}
+
+	var filtered []Balance
+	for _, b := range balances {
+		if f.Match(b) {
+			filtered = append(filtered, b)
+		}
+	}
+	return filtered
+}
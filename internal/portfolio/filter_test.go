@@ -0,0 +1,37 @@
+package portfolio
+
+import (
+	"testing"
+
+	"github.com/anilcse/cosmoscope/internal/config"
+	"github.com/anilcse/cosmoscope/pkg/utils"
+)
+
+func TestFilterBalances(t *testing.T) {
+	balances := testBalances()
+	balances = append(balances, Balance{Account: "osmo1xyz", Network: "osmosis-bank", Token: "OSMO", USDValue: utils.ParseAmount("5", 0)})
+	LabelBalances(balances, map[string]config.Address{
+		"osmo1xyz": {Address: "osmo1xyz", Label: "Treasury", Owner: "treasury", Tags: []string{"treasury", "ops"}},
+	})
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   int
+	}{
+		{"no filter", Filter{}, 3},
+		{"chain name", Filter{Network: "cosmoshub"}, 2},
+		{"network label", Filter{Network: "cosmoshub-staking"}, 1},
+		{"token", Filter{Token: "osmo"}, 1},
+		{"account", Filter{Account: "cosmos1abc"}, 2},
+		{"min usd", Filter{MinUSD: utils.ParseAmount("10", 0)}, 2},
+		{"label", Filter{Account: "treasury"}, 1},
+		{"owner", Filter{Owner: "treasury"}, 1},
+		{"tag", Filter{Tag: "ops"}, 1},
+	}
+	for _, tt := range tests {
+		if got := len(FilterBalances(balances, tt.filter)); got != tt.want {
+			t.Errorf("%s: got %d balances, want %d", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package portfolio
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/anilcse/cosmoscope/pkg/utils"
+	"github.com/olekukonko/tablewriter"
+)
+
+// maxHistoryColumns limits how many snapshots the per-token and per-network
+// tables show side by side.
+const maxHistoryColumns = 6
+
+// PrintHistory prints total value over time followed by the value of each
+// token and network across the most recent snapshots.
+func PrintHistory(snapshots []Snapshot) {
+	if len(snapshots) == 0 {
+		fmt.Println("No snapshots stored yet.")
+		return
+	}
+
+	printTotalHistory(snapshots)
+
+	recent := snapshots
+	if len(recent) > maxHistoryColumns {
+		recent = recent[len(recent)-maxHistoryColumns:]
+	}
+
+	printValueHistory("Token Value Over Time:", "Token", recent, func(b Balance) string {
+		return b.Token
+	})
+	printValueHistory("Network Value Over Time:", "Network", recent, func(b Balance) string {
+		return strings.Split(b.Network, "-")[0]
+	})
+}
+
+func printTotalHistory(snapshots []Snapshot) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Snapshot", "Time", "Total USD", "Change", "Change %"})
+	table.SetAutoMergeCells(false)
+	table.SetRowLine(true)
+
+	// Set all headers to bold
+	table.SetHeaderColor(
+		tablewriter.Colors{tablewriter.Bold},
+		tablewriter.Colors{tablewriter.Bold},
+		tablewriter.Colors{tablewriter.Bold},
+		tablewriter.Colors{tablewriter.Bold},
+		tablewriter.Colors{tablewriter.Bold},
+	)
+
+	var previous utils.Amount
+	for i, snapshot := range snapshots {
+		total := snapshot.TotalValue()
+		change, changePct := "-", "-"
+		if i > 0 {
+			delta := total.Sub(previous)
+			change = fmt.Sprintf("%+.2f", delta.Float64())
+			if prev := previous.Float64(); prev != 0 {
+				changePct = fmt.Sprintf("%+.2f%%", delta.Float64()/prev*100)
+			}
+		}
+
+		color := tablewriter.Colors{}
+		if i > 0 && total.Cmp(previous) < 0 {
+			color = tablewriter.Colors{tablewriter.FgRedColor}
+		} else if i > 0 && total.Cmp(previous) > 0 {
+			color = tablewriter.Colors{tablewriter.FgGreenColor}
+		}
+
+		table.Rich([]string{
+			snapshot.ID(),
+			snapshot.Timestamp.Local().Format("2006-01-02 15:04:05"),
+			fmt.Sprintf("$%.2f", total.Float64()),
+			change,
+			changePct,
+		}, []tablewriter.Colors{{}, {}, {}, color, color})
+
+		previous = total
+	}
+
+	titleColor.Println("Portfolio History:")
+	table.Render()
+	fmt.Println()
+}
+
+func printValueHistory(title, label string, snapshots []Snapshot, keyFn func(Balance) string) {
+	values := make(map[string][]utils.Amount)
+	for i, snapshot := range snapshots {
+		for _, b := range snapshot.Balances {
+			key := keyFn(b)
+			if _, exists := values[key]; !exists {
+				values[key] = make([]utils.Amount, len(snapshots))
+			}
+			values[key][i] = values[key][i].Add(b.USDValue)
+		}
+	}
+
+	// Sort rows by value in the latest snapshot
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	last := len(snapshots) - 1
+	sort.Slice(keys, func(i, j int) bool {
+		return values[keys[i]][last].Cmp(values[keys[j]][last]) > 0
+	})
+
+	header := []string{label}
+	headerColors := []tablewriter.Colors{{tablewriter.Bold}}
+	for _, snapshot := range snapshots {
+		header = append(header, snapshot.Timestamp.Local().Format("01-02 15:04"))
+		headerColors = append(headerColors, tablewriter.Colors{tablewriter.Bold})
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader(header)
+	table.SetAutoMergeCells(false)
+	table.SetRowLine(true)
+	table.SetHeaderColor(headerColors...)
+
+	for _, key := range keys {
+		row := []string{key}
+		for _, value := range values[key] {
+			row = append(row, fmt.Sprintf("$%.2f", value.Float64()))
+		}
+		table.Append(row)
+	}
+
+	titleColor.Println(title)
+	table.Render()
+	fmt.Println()
+}
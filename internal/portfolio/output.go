@@ -0,0 +1,314 @@
+package portfolio
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/anilcse/cosmoscope/pkg/utils"
+)
+
+// Output formats accepted by WriteReport.
+const (
+	OutputTable  = "table"
+	OutputJSON   = "json"
+	OutputCSV    = "csv"
+	OutputNDJSON = "ndjson"
+)
+
+// Report is the machine-readable form of PrintBalanceReport.
+type Report struct {
+	GeneratedAt time.Time       `json:"generated_at"`
+	TotalUSD    utils.Amount    `json:"total_usd"`
+	Balances    []ReportBalance `json:"balances"`
+	Tokens      []TokenSummary  `json:"tokens"`
+	Networks    []Distribution  `json:"networks"`
+	AssetTypes  []Distribution  `json:"asset_types"`
+	Owners      []Distribution  `json:"owners,omitempty"`
+	Tags        []Distribution  `json:"tags,omitempty"`
+	Keys        []KeyGroup      `json:"keys,omitempty"`
+	Filtered    []FilteredToken `json:"filtered,omitempty"`
+}
+
+// ReportBalance is a balance together with its asset type label.
+type ReportBalance struct {
+	Balance
+	AssetType string `json:"asset_type"`
+}
+
+// Distribution is the value held in one network, asset type, owner or tag.
+type Distribution struct {
+	Name     string       `json:"name"`
+	USDValue utils.Amount `json:"usd_value"`
+	Share    float64      `json:"share_pct"`
+}
+
+// ValidOutput reports whether format is a supported output format.
+func ValidOutput(format string) bool {
+	switch format {
+	case OutputTable, OutputJSON, OutputCSV, OutputNDJSON:
+		return true
+	}
+	return false
+}
+
+func BuildReport(balances []Balance) Report {
+	sorted := append([]Balance(nil), balances...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].USDValue.Cmp(sorted[j].USDValue) > 0
+	})
+
+	report := Report{
+		GeneratedAt: time.Now().UTC(),
+		Tokens:      SummarizeTokens(balances),
+		Networks: summarizeBy(balances, func(b Balance) string {
+			return strings.Split(b.Network, "-")[0]
+		}),
+		AssetTypes: summarizeBy(balances, getAssetType),
+		Owners:     ownerDistribution(balances),
+		Tags:       tagDistribution(balances),
+		Keys:       GroupByKey(balances),
+	}
+	for _, b := range sorted {
+		report.TotalUSD = report.TotalUSD.Add(b.USDValue)
+		report.Balances = append(report.Balances, ReportBalance{Balance: b, AssetType: getAssetType(b)})
+	}
+	return report
+}
+
+// SummarizeTokens totals balances per token, largest USD value first.
+func SummarizeTokens(balances []Balance) []TokenSummary {
+	byToken := make(map[string]*TokenSummary)
+	var total utils.Amount
+	for _, b := range balances {
+		summary, exists := byToken[b.Token]
+		if !exists {
+			summary = &TokenSummary{TokenName: b.Token}
+			byToken[b.Token] = summary
+		}
+		summary.Balance = summary.Balance.Add(b.Amount)
+		summary.USDValue = summary.USDValue.Add(b.USDValue)
+		total = total.Add(b.USDValue)
+	}
+
+	var summaries []TokenSummary
+	for _, summary := range byToken {
+		summary.Share = share(summary.USDValue, total)
+		summaries = append(summaries, *summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if c := summaries[i].USDValue.Cmp(summaries[j].USDValue); c != 0 {
+			return c > 0
+		}
+		return summaries[i].TokenName < summaries[j].TokenName
+	})
+	return summaries
+}
+
+func summarizeBy(balances []Balance, group func(Balance) string) []Distribution {
+	values := make(map[string]utils.Amount)
+	var total utils.Amount
+	for _, b := range balances {
+		name := group(b)
+		values[name] = values[name].Add(b.USDValue)
+		total = total.Add(b.USDValue)
+	}
+
+	var distribution []Distribution
+	for name, value := range values {
+		distribution = append(distribution, Distribution{Name: name, USDValue: value, Share: share(value, total)})
+	}
+	sort.Slice(distribution, func(i, j int) bool {
+		if c := distribution[i].USDValue.Cmp(distribution[j].USDValue); c != 0 {
+			return c > 0
+		}
+		return distribution[i].Name < distribution[j].Name
+	})
+	return distribution
+}
+
+// ownerDistribution groups value by owner, or returns nothing when no
+// account has an owner.
+func ownerDistribution(balances []Balance) []Distribution {
+	for _, b := range balances {
+		if b.Owner != "" {
+			return summarizeBy(balances, func(b Balance) string {
+				if b.Owner == "" {
+					return "Unassigned"
+				}
+				return b.Owner
+			})
+		}
+	}
+	return nil
+}
+
+// tagDistribution totals the value of the accounts carrying each tag.
+// Shares are of the whole portfolio.
+func tagDistribution(balances []Balance) []Distribution {
+	values := make(map[string]utils.Amount)
+	var total utils.Amount
+	for _, b := range balances {
+		total = total.Add(b.USDValue)
+		for _, tag := range b.Tags {
+			values[tag] = values[tag].Add(b.USDValue)
+		}
+	}
+
+	var distribution []Distribution
+	for tag, value := range values {
+		distribution = append(distribution, Distribution{Name: tag, USDValue: value, Share: share(value, total)})
+	}
+	sort.Slice(distribution, func(i, j int) bool {
+		if c := distribution[i].USDValue.Cmp(distribution[j].USDValue); c != 0 {
+			return c > 0
+		}
+		return distribution[i].Name < distribution[j].Name
+	})
+	return distribution
+}
+
+func share(value, total utils.Amount) float64 {
+	if total.Sign() == 0 {
+		return 0
+	}
+	return value.Float64() / total.Float64() * 100
+}
+
+// WriteReport writes balances, and the tokens the spam policies left out of
+// them, to w in a machine-readable format.
+func WriteReport(w io.Writer, format string, balances []Balance, filtered []FilteredToken) error {
+	report := BuildReport(balances)
+	report.Filtered = filtered
+	switch format {
+	case OutputJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case OutputNDJSON:
+		return writeNDJSON(w, report)
+	case OutputCSV:
+		return writeCSV(w, report)
+	default:
+		return fmt.Errorf("unsupported output format %q", format)
+	}
+}
+
+// writeNDJSON writes one object per line, each tagged with a "record" field
+// naming the section it belongs to.
+func writeNDJSON(w io.Writer, report Report) error {
+	encoder := json.NewEncoder(w)
+	for _, b := range report.Balances {
+		if err := encoder.Encode(struct {
+			Record string `json:"record"`
+			ReportBalance
+		}{"balance", b}); err != nil {
+			return err
+		}
+	}
+	for _, t := range report.Tokens {
+		if err := encoder.Encode(struct {
+			Record string `json:"record"`
+			TokenSummary
+		}{"token", t}); err != nil {
+			return err
+		}
+	}
+	for _, section := range []struct {
+		record string
+		rows   []Distribution
+	}{{"network", report.Networks}, {"asset_type", report.AssetTypes}, {"owner", report.Owners}, {"tag", report.Tags}} {
+		for _, d := range section.rows {
+			if err := encoder.Encode(struct {
+				Record string `json:"record"`
+				Distribution
+			}{section.record, d}); err != nil {
+				return err
+			}
+		}
+	}
+	for _, k := range report.Keys {
+		if err := encoder.Encode(struct {
+			Record string `json:"record"`
+			KeyGroup
+		}{"key", k}); err != nil {
+			return err
+		}
+	}
+	for _, f := range report.Filtered {
+		if err := encoder.Encode(struct {
+			Record string `json:"record"`
+			FilteredToken
+		}{"filtered", f}); err != nil {
+			return err
+		}
+	}
+	return encoder.Encode(struct {
+		Record      string       `json:"record"`
+		GeneratedAt time.Time    `json:"generated_at"`
+		TotalUSD    utils.Amount `json:"total_usd"`
+	}{"total", report.GeneratedAt, report.TotalUSD})
+}
+
+// csvHeader is shared by every CSV row; the record column names the
+// section and columns that do not apply to it are left empty.
+var csvHeader = []string{"record", "account", "network", "token", "asset_type", "amount", "usd_value", "share_pct", "price_id", "price_source", "label", "owner", "tags", "key", "contract", "reason"}
+
+func writeCSV(w io.Writer, report Report) error {
+	writer := csv.NewWriter(w)
+	rows := [][]string{csvHeader}
+
+	for _, b := range report.Balances {
+		rows = append(rows, []string{"balance", b.Account, b.Network, b.Token, b.AssetType,
+			b.Amount.String(), b.USDValue.String(), "", b.PriceID, b.PriceSource,
+			b.Label, b.Owner, strings.Join(b.Tags, ";")})
+	}
+	for _, t := range report.Tokens {
+		rows = append(rows, []string{"token", "", "", t.TokenName, "",
+			t.Balance.String(), t.USDValue.String(), formatShare(t.Share), "", "", "", "", ""})
+	}
+	for _, d := range report.Networks {
+		rows = append(rows, []string{"network", "", d.Name, "", "",
+			"", d.USDValue.String(), formatShare(d.Share), "", "", "", "", ""})
+	}
+	for _, d := range report.AssetTypes {
+		rows = append(rows, []string{"asset_type", "", "", "", d.Name,
+			"", d.USDValue.String(), formatShare(d.Share), "", "", "", "", ""})
+	}
+	for _, d := range report.Owners {
+		rows = append(rows, []string{"owner", "", "", "", "",
+			"", d.USDValue.String(), formatShare(d.Share), "", "", "", d.Name, ""})
+	}
+	for _, d := range report.Tags {
+		rows = append(rows, []string{"tag", "", "", "", "",
+			"", d.USDValue.String(), formatShare(d.Share), "", "", "", "", d.Name})
+	}
+	// Key rows list the accounts and networks the key covers, ;-separated.
+	for _, k := range report.Keys {
+		rows = append(rows, []string{"key", strings.Join(k.Accounts, ";"), strings.Join(k.Networks, ";"), "", "",
+			"", k.USDValue.String(), formatShare(k.Share), "", "", "", "", "", "0x" + k.HexAddr})
+	}
+	for _, f := range report.Filtered {
+		rows = append(rows, []string{"filtered", f.Account, f.Network, f.Symbol, "",
+			"", "", "", "", "", "", "", "", "", f.Contract, f.Reason})
+	}
+	rows = append(rows, []string{"total", "", "", "", "", "", report.TotalUSD.String(), "100", "", "", "", "", ""})
+
+	// Only key and filtered rows use the trailing columns.
+	for i, row := range rows {
+		rows[i] = append(row, make([]string, len(csvHeader)-len(row))...)
+	}
+
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+func formatShare(share float64) string {
+	return fmt.Sprintf("%.2f", share)
+}
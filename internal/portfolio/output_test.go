@@ -0,0 +1,124 @@
+package portfolio
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anilcse/cosmoscope/pkg/utils"
+)
+
+func testBalances() []Balance {
+	return []Balance{
+		{Account: "cosmos1abc", Network: "cosmoshub-bank", Token: "ATOM", Amount: utils.ParseAmount("1000000", 6), USDValue: utils.ParseAmount("10", 0)},
+		{Account: "cosmos1abc", Network: "cosmoshub-staking", Token: "ATOM", Amount: utils.ParseAmount("3000000", 6), USDValue: utils.ParseAmount("30", 0)},
+	}
+}
+
+func testFiltered() []FilteredToken {
+	return []FilteredToken{
+		{Network: "ethereum", Account: "0xabc", Contract: "0xdead", Symbol: "FREE", Reason: "suspicious symbol"},
+	}
+}
+
+func TestWriteReportCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, OutputCSV, testBalances(), testFiltered()); err != nil {
+		t.Fatalf("WriteReport() error = %v", err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	if got := strings.Join(rows[0], ","); got != strings.Join(csvHeader, ",") {
+		t.Errorf("header = %v", got)
+	}
+	// 2 balances, 1 token, 1 network, 2 asset types, 1 filtered token and
+	// the total.
+	if len(rows) != 9 {
+		t.Fatalf("len(rows) = %d, want 9", len(rows))
+	}
+	if got := rows[1][4]; got != "Staking" {
+		t.Errorf("largest balance asset type = %v, want Staking", got)
+	}
+	if got := rows[3][7]; got != "100.00" {
+		t.Errorf("token share = %v, want 100.00", got)
+	}
+	if got := rows[7]; got[0] != "filtered" || got[14] != "0xdead" || got[15] != "suspicious symbol" {
+		t.Errorf("filtered row = %v", got)
+	}
+}
+
+func TestWriteReportCSVKeyRows(t *testing.T) {
+	balances := []Balance{
+		{Account: "cosmos1abc", Network: "cosmoshub-bank", HexAddr: "ab", Token: "ATOM", USDValue: utils.ParseAmount("10", 0)},
+		{Account: "osmo1abc", Network: "osmosis-bank", HexAddr: "ab", Token: "OSMO", USDValue: utils.ParseAmount("5", 0)},
+	}
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, OutputCSV, balances, nil); err != nil {
+		t.Fatalf("WriteReport() error = %v", err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+
+	for _, row := range rows {
+		if row[0] != "key" {
+			continue
+		}
+		if row[1] != "cosmos1abc;osmo1abc" || row[2] != "cosmoshub;osmosis" || row[10] != "" || row[13] != "0xab" {
+			t.Errorf("key row = %v", row)
+		}
+		return
+	}
+	t.Error("no key row")
+}
+
+func TestWriteReportNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, OutputNDJSON, testBalances(), testFiltered()); err != nil {
+		t.Fatalf("WriteReport() error = %v", err)
+	}
+
+	records := make(map[string]int)
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record struct {
+			Record string `json:"record"`
+		}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("invalid line %q: %v", line, err)
+		}
+		records[record.Record]++
+	}
+
+	want := map[string]int{"balance": 2, "token": 1, "network": 1, "asset_type": 2, "filtered": 1, "total": 1}
+	for record, count := range want {
+		if records[record] != count {
+			t.Errorf("%s records = %d, want %d", record, records[record], count)
+		}
+	}
+}
+
+func TestWriteReportJSONCompletionTime(t *testing.T) {
+	completes := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	balances := append(testBalances(), Balance{
+		Account: "cosmos1abc", Network: "cosmoshub-unbonding", Token: "ATOM",
+		Amount: utils.ParseAmount("1", 0), CompletionTime: &completes,
+	})
+
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, OutputJSON, balances, nil); err != nil {
+		t.Fatalf("WriteReport() error = %v", err)
+	}
+	if got := strings.Count(buf.String(), `"completion_time"`); got != 1 {
+		t.Errorf("completion_time written %d times, want only for the unbonding entry:\n%s", got, buf.String())
+	}
+	if !strings.Contains(buf.String(), `"completion_time": "2030-01-02T03:04:05Z"`) {
+		t.Errorf("unbonding completion time missing:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,44 @@
+package portfolio
+
+import (
+	"time"
+
+	"github.com/anilcse/cosmoscope/internal/price"
+	"github.com/anilcse/cosmoscope/pkg/utils"
+)
+
+// snapshotIDFormat is how snapshots are named on the command line.
+const snapshotIDFormat = "20060102-150405"
+
+// Snapshot is the persisted result of one run.
+type Snapshot struct {
+	Timestamp time.Time          `json:"timestamp"`
+	Balances  []Balance          `json:"balances"`
+	Prices    map[string]float64 `json:"prices"`
+}
+
+func NewSnapshot(balances []Balance) Snapshot {
+	return Snapshot{
+		Timestamp: time.Now().UTC(),
+		Balances:  balances,
+		Prices:    price.Current(),
+	}
+}
+
+// ID returns the second-resolution UTC timestamp that identifies the snapshot.
+func (s Snapshot) ID() string {
+	return s.Timestamp.UTC().Format(snapshotIDFormat)
+}
+
+// ParseSnapshotID returns the second an ID names.
+func ParseSnapshotID(id string) (time.Time, error) {
+	return time.ParseInLocation(snapshotIDFormat, id, time.UTC)
+}
+
+func (s Snapshot) TotalValue() utils.Amount {
+	var total utils.Amount
+	for _, b := range s.Balances {
+		total = total.Add(b.USDValue)
+	}
+	return total
+}
@@ -4,48 +4,119 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"sort"
 	"strings"
 	"time"
 )
 
-var prices map[string]float64
+const (
+	defaultCoinGeckoURL = "https://api.coingecko.com/api/v3/coins/markets?vs_currency=usd"
+	// coinGeckoPageSize is the largest page the markets endpoint returns.
+	coinGeckoPageSize = 250
+)
 
 type CoinGeckoResponse []struct {
+	ID           string  `json:"id"`
 	Symbol       string  `json:"symbol"`
 	CurrentPrice float64 `json:"current_price"`
 }
 
-func InitializePrices(url string) {
-	prices = fetchPrices(url)
-	if prices == nil {
-		fmt.Println("Error: Failed to fetch prices. Proceeding with zero USD values.")
-		prices = make(map[string]float64)
+// CoinGecko prices tokens from the CoinGecko /coins/markets endpoint. The
+// ids= query is built from the CoinGecko IDs of the requested tokens, plus
+// any ids already present in URL.
+type CoinGecko struct {
+	URL string
+}
+
+func (c *CoinGecko) Name() string {
+	return "coingecko"
+}
+
+func (c *CoinGecko) FetchPrices(tokens []Token) (Prices, error) {
+	base := c.URL
+	if base == "" {
+		base = defaultCoinGeckoURL
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return Prices{}, fmt.Errorf("invalid CoinGecko URL: %v", err)
+	}
+
+	ids := c.ids(u, tokens)
+	if len(ids) == 0 {
+		return newPrices(), nil
+	}
+
+	prices := newPrices()
+	for start := 0; start < len(ids); start += coinGeckoPageSize {
+		end := start + coinGeckoPageSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		query := u.Query()
+		if query.Get("vs_currency") == "" {
+			query.Set("vs_currency", "usd")
+		}
+		query.Set("ids", strings.Join(ids[start:end], ","))
+		query.Set("per_page", fmt.Sprint(coinGeckoPageSize))
+		u.RawQuery = query.Encode()
+
+		if err := fetchCoinGeckoPage(u.String(), prices); err != nil {
+			return Prices{}, err
+		}
 	}
+	return prices, nil
 }
 
-func fetchPrices(url string) map[string]float64 {
+func (c *CoinGecko) ids(u *url.URL, tokens []Token) []string {
+	seen := make(map[string]bool)
+	var ids []string
+	add := func(id string) {
+		id = strings.TrimSpace(id)
+		if id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	for _, id := range strings.Split(u.Query().Get("ids"), ",") {
+		add(id)
+	}
+	for _, token := range tokens {
+		add(token.ID)
+	}
+
+	sort.Strings(ids)
+	return ids
+}
+
+func fetchCoinGeckoPage(pageURL string, prices Prices) error {
 	client := &http.Client{Timeout: time.Second * 10}
-	resp, err := client.Get(url)
+	resp, err := client.Get(pageURL)
 	if err != nil {
-		return nil
+		return err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
 	var response CoinGeckoResponse
 	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return nil
+		return err
 	}
 
-	prices = make(map[string]float64)
+	// Results are ordered by market cap, so when several coins share a
+	// ticker the symbol fallback keeps the largest one.
 	for _, coin := range response {
-		prices[strings.ToUpper(coin.Symbol)] = coin.CurrentPrice
-	}
-	return prices
-}
-
-func CalculateUSDValue(token string, amount float64) float64 {
-	if price, ok := prices[strings.ToUpper(token)]; ok {
-		return amount * price
+		prices.ByID[coin.ID] = coin.CurrentPrice
+		symbol := strings.ToUpper(coin.Symbol)
+		if _, exists := prices.BySymbol[symbol]; !exists {
+			prices.BySymbol[symbol] = coin.CurrentPrice
+		}
 	}
-	return 0
+	return nil
 }
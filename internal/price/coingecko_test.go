@@ -0,0 +1,58 @@
+package price
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCoinGeckoPaginatesIDs(t *testing.T) {
+	var pages []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ids := strings.Split(r.URL.Query().Get("ids"), ",")
+		pages = append(pages, len(ids))
+		if r.URL.Query().Get("vs_currency") != "usd" {
+			t.Errorf("vs_currency = %q", r.URL.Query().Get("vs_currency"))
+		}
+		var coins []string
+		for _, id := range ids {
+			coins = append(coins, fmt.Sprintf(`{"id":%q,"symbol":"t","current_price":2}`, id))
+		}
+		fmt.Fprintf(w, "[%s]", strings.Join(coins, ","))
+	}))
+	defer server.Close()
+
+	var tokens []Token
+	for i := 0; i < 300; i++ {
+		tokens = append(tokens, Token{ID: fmt.Sprintf("coin-%03d", i)})
+	}
+	prices, err := (&CoinGecko{URL: server.URL}).FetchPrices(tokens)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) != 2 || pages[0] != coinGeckoPageSize || pages[1] != 50 {
+		t.Errorf("requested pages of %v ids, want [250 50]", pages)
+	}
+	if len(prices.ByID) != 300 || prices.ByID["coin-299"] != 2 {
+		t.Errorf("got %d prices", len(prices.ByID))
+	}
+}
+
+func TestCoinGeckoErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	if _, err := (&CoinGecko{URL: server.URL}).FetchPrices([]Token{{ID: "cosmos"}}); err == nil || !strings.Contains(err.Error(), "status 429") {
+		t.Errorf("error = %v, want the status", err)
+	}
+
+	// Without any IDs nothing is requested.
+	prices, err := (&CoinGecko{URL: server.URL}).FetchPrices([]Token{{Symbol: "ATOM"}})
+	if err != nil || len(prices.ByID) != 0 {
+		t.Errorf("symbol-only tokens = %v, %v", prices, err)
+	}
+}
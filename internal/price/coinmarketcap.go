@@ -0,0 +1,89 @@
+package price
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const defaultCoinMarketCapURL = "https://pro-api.coinmarketcap.com/v2/cryptocurrency/quotes/latest"
+
+type CoinMarketCapResponse struct {
+	Status struct {
+		ErrorCode    int    `json:"error_code"`
+		ErrorMessage string `json:"error_message"`
+	} `json:"status"`
+	Data map[string][]struct {
+		Symbol string `json:"symbol"`
+		Quote  struct {
+			USD struct {
+				Price float64 `json:"price"`
+			} `json:"USD"`
+		} `json:"quote"`
+	} `json:"data"`
+}
+
+// CoinMarketCap prices tokens by symbol from the CoinMarketCap quotes API.
+type CoinMarketCap struct {
+	URL    string
+	APIKey string
+}
+
+func (c *CoinMarketCap) Name() string {
+	return "coinmarketcap"
+}
+
+func (c *CoinMarketCap) FetchPrices(tokens []Token) (Prices, error) {
+	if c.APIKey == "" {
+		return Prices{}, fmt.Errorf("no CoinMarketCap API key configured")
+	}
+
+	var symbols []string
+	for _, token := range tokens {
+		symbols = append(symbols, token.Symbol)
+	}
+
+	endpoint := c.URL
+	if endpoint == "" {
+		endpoint = defaultCoinMarketCapURL
+	}
+	query := url.Values{}
+	query.Set("symbol", strings.Join(symbols, ","))
+	query.Set("convert", "USD")
+	query.Set("skip_invalid", "true")
+
+	req, err := http.NewRequest("GET", endpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return Prices{}, err
+	}
+	req.Header.Add("Accept", "application/json")
+	req.Header.Add("X-CMC_PRO_API_KEY", c.APIKey)
+
+	client := &http.Client{Timeout: time.Second * 10}
+	resp, err := client.Do(req)
+	if err != nil {
+		return Prices{}, err
+	}
+	defer resp.Body.Close()
+
+	var response CoinMarketCapResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return Prices{}, err
+	}
+	if response.Status.ErrorCode != 0 {
+		return Prices{}, fmt.Errorf("coinmarketcap error %d: %s", response.Status.ErrorCode, response.Status.ErrorMessage)
+	}
+
+	prices := newPrices()
+	for symbol, entries := range response.Data {
+		// Entries are ordered by market cap rank, so the first one is the
+		// asset most commonly meant by the ticker.
+		if len(entries) > 0 && entries[0].Quote.USD.Price > 0 {
+			prices.BySymbol[strings.ToUpper(symbol)] = entries[0].Quote.USD.Price
+		}
+	}
+	return prices, nil
+}
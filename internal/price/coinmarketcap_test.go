@@ -0,0 +1,43 @@
+package price
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCoinMarketCap(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-CMC_PRO_API_KEY"); got != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"status":{"error_code":1002,"error_message":"API key missing."}}`))
+			return
+		}
+		if got := r.URL.Query().Get("symbol"); got != "ATOM,OSMO" {
+			t.Errorf("symbol query = %q", got)
+		}
+		w.Write([]byte(`{"status":{"error_code":0},"data":{
+			"ATOM":[{"symbol":"ATOM","quote":{"USD":{"price":7.5}}},{"symbol":"ATOM","quote":{"USD":{"price":0.01}}}],
+			"OSMO":[{"symbol":"OSMO","quote":{"USD":{"price":0}}}]
+		}}`))
+	}))
+	defer server.Close()
+
+	tokens := []Token{{Symbol: "ATOM"}, {Symbol: "OSMO"}}
+	prices, err := (&CoinMarketCap{URL: server.URL, APIKey: "secret"}).FetchPrices(tokens)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prices.BySymbol) != 1 || prices.BySymbol["ATOM"] != 7.5 {
+		t.Errorf("prices = %v, want the highest ranked ATOM and no zero price", prices.BySymbol)
+	}
+
+	_, err = (&CoinMarketCap{URL: server.URL, APIKey: "wrong"}).FetchPrices(tokens)
+	if err == nil || !strings.Contains(err.Error(), "1002") {
+		t.Errorf("error = %v, want the CoinMarketCap error code", err)
+	}
+	if _, err := (&CoinMarketCap{URL: server.URL}).FetchPrices(tokens); err == nil {
+		t.Error("no error without an API key")
+	}
+}
@@ -0,0 +1,88 @@
+package price
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anilcse/cosmoscope/internal/config"
+)
+
+const defaultOsmosisURL = "https://lcd.osmosis.zone"
+
+// Osmosis prices tokens from the spot price of configured pools quoted in
+// a USD stablecoin.
+type Osmosis struct {
+	URL   string
+	Pools map[string]config.OsmosisPool
+}
+
+type osmosisSpotPriceResponse struct {
+	SpotPrice string `json:"spot_price"`
+}
+
+func (o *Osmosis) Name() string {
+	return "osmosis"
+}
+
+func (o *Osmosis) FetchPrices(tokens []Token) (Prices, error) {
+	endpoint := o.URL
+	if endpoint == "" {
+		endpoint = defaultOsmosisURL
+	}
+
+	pools := make(map[string]config.OsmosisPool)
+	for symbol, pool := range o.Pools {
+		pools[strings.ToUpper(symbol)] = pool
+	}
+
+	client := &http.Client{Timeout: time.Second * 10}
+	prices := newPrices()
+	for _, token := range tokens {
+		symbol := token.Symbol
+		pool, ok := pools[symbol]
+		if !ok {
+			continue
+		}
+
+		query := url.Values{}
+		query.Set("base_asset_denom", pool.BaseDenom)
+		query.Set("quote_asset_denom", pool.QuoteDenom)
+		reqURL := fmt.Sprintf("%s/osmosis/poolmanager/v1beta1/pools/%d/prices?%s", endpoint, pool.PoolID, query.Encode())
+
+		spot, err := fetchSpotPrice(client, reqURL)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error fetching Osmosis pool %d price for %s: %v\n", pool.PoolID, symbol, err)
+			continue
+		}
+
+		// The spot price is quote base units per base unit, so scale it
+		// by the difference in decimals to get a display price.
+		prices.BySymbol[symbol] = spot * math.Pow10(pool.BaseDecimals-pool.QuoteDecimals)
+	}
+	return prices, nil
+}
+
+func fetchSpotPrice(client *http.Client, reqURL string) (float64, error) {
+	resp, err := client.Get(reqURL)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var response osmosisSpotPriceResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(response.SpotPrice, 64)
+}
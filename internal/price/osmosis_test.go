@@ -0,0 +1,44 @@
+package price
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/anilcse/cosmoscope/internal/config"
+)
+
+func TestOsmosis(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/osmosis/poolmanager/v1beta1/pools/1/prices":
+			if r.URL.Query().Get("base_asset_denom") != "uosmo" || r.URL.Query().Get("quote_asset_denom") != "uusdc" {
+				t.Errorf("query = %s", r.URL.RawQuery)
+			}
+			w.Write([]byte(`{"spot_price":"0.45"}`))
+		case "/osmosis/poolmanager/v1beta1/pools/2/prices":
+			// 1 wei of an 18 decimal token is worth 2e-12 of a 6 decimal
+			// stablecoin unit, i.e. $2 per token.
+			w.Write([]byte(`{"spot_price":"0.000000000002"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	osmosis := &Osmosis{URL: server.URL, Pools: map[string]config.OsmosisPool{
+		"osmo": {PoolID: 1, BaseDenom: "uosmo", BaseDecimals: 6, QuoteDenom: "uusdc", QuoteDecimals: 6},
+		"WETH": {PoolID: 2, BaseDenom: "weth-wei", BaseDecimals: 18, QuoteDenom: "uusdc", QuoteDecimals: 6},
+		"GONE": {PoolID: 3, BaseDenom: "ugone", QuoteDenom: "uusdc"},
+	}}
+	prices, err := osmosis.FetchPrices([]Token{{Symbol: "OSMO"}, {Symbol: "WETH"}, {Symbol: "GONE"}, {Symbol: "ATOM"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prices.BySymbol) != 2 || prices.BySymbol["OSMO"] != 0.45 {
+		t.Errorf("prices = %v", prices.BySymbol)
+	}
+	if got := prices.BySymbol["WETH"]; got < 1.999999 || got > 2.000001 {
+		t.Errorf("WETH = %v, want 2 after scaling by decimals", got)
+	}
+}
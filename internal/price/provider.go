@@ -0,0 +1,206 @@
+package price
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/anilcse/cosmoscope/internal/config"
+	"github.com/anilcse/cosmoscope/pkg/utils"
+)
+
+// Token identifies something to price: its CoinGecko ID when known, with
+// the symbol as a fallback for providers (or tokens) without IDs.
+type Token struct {
+	ID     string
+	Symbol string
+}
+
+// Prices holds USD prices keyed by CoinGecko ID and by uppercase symbol.
+type Prices struct {
+	ByID     map[string]float64
+	BySymbol map[string]float64
+}
+
+func newPrices() Prices {
+	return Prices{ByID: make(map[string]float64), BySymbol: make(map[string]float64)}
+}
+
+// Provider fetches USD prices for tokens. Providers may return more prices
+// than requested; only the requested ones are used.
+type Provider interface {
+	Name() string
+	FetchPrices(tokens []Token) (Prices, error)
+}
+
+type quote struct {
+	price  float64
+	source string
+}
+
+var (
+	prices     = make(map[string]quote)
+	pricesLock sync.RWMutex
+)
+
+// NewProviders builds the provider chain described by the configuration.
+// Without explicit providers it falls back to CoinGecko on coingeckoURI.
+func NewProviders(cfgs []config.PriceProvider, coingeckoURI string) ([]Provider, error) {
+	if len(cfgs) == 0 {
+		return []Provider{&CoinGecko{URL: coingeckoURI}}, nil
+	}
+
+	var providers []Provider
+	for _, cfg := range cfgs {
+		switch strings.ToLower(cfg.Type) {
+		case "coingecko":
+			url := cfg.URL
+			if url == "" {
+				url = coingeckoURI
+			}
+			providers = append(providers, &CoinGecko{URL: url})
+		case "coinmarketcap":
+			providers = append(providers, &CoinMarketCap{URL: cfg.URL, APIKey: cfg.APIKey})
+		case "osmosis":
+			providers = append(providers, &Osmosis{URL: cfg.URL, Pools: cfg.Pools})
+		case "static":
+			providers = append(providers, &Static{File: cfg.File})
+		default:
+			return nil, fmt.Errorf("unknown price provider type %q", cfg.Type)
+		}
+	}
+	return providers, nil
+}
+
+// InitializePrices asks each provider, in priority order, for the tokens
+// that are still unpriced. A token is matched by CoinGecko ID first and by
+// symbol only when the provider has no price for its ID. A token no provider
+// prices keeps its quote from the previous call, so a failed refresh does not
+// zero out a long-running watch or exporter. The returned error lists the
+// providers that failed.
+func InitializePrices(providers []Provider, tokens []Token) error {
+	pricesLock.Lock()
+	defer pricesLock.Unlock()
+
+	previous := prices
+	prices = make(map[string]quote)
+	missing := uniqueTokens(tokens)
+
+	var failed []string
+	for _, provider := range providers {
+		if len(missing) == 0 {
+			break
+		}
+
+		fetched, err := provider.FetchPrices(missing)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", provider.Name(), err))
+			continue
+		}
+
+		var stillMissing []Token
+		for _, token := range missing {
+			if price, ok := fetched.ByID[token.ID]; ok && token.ID != "" {
+				prices[token.Key()] = quote{price: price, source: provider.Name()}
+			} else if price, ok := fetched.BySymbol[token.Symbol]; ok {
+				prices[token.Key()] = quote{price: price, source: provider.Name() + " (symbol)"}
+			} else {
+				stillMissing = append(stillMissing, token)
+			}
+		}
+		missing = stillMissing
+	}
+
+	var stale, unpriced []string
+	for _, token := range missing {
+		if q, ok := previous[token.Key()]; ok {
+			prices[token.Key()] = q
+			stale = append(stale, token.String())
+		} else {
+			unpriced = append(unpriced, token.String())
+		}
+	}
+	if len(stale) > 0 {
+		fmt.Fprintf(os.Stderr, "Using the last known price for: %s\n", strings.Join(stale, ", "))
+	}
+	if len(unpriced) > 0 {
+		fmt.Fprintf(os.Stderr, "No price found for: %s\n", strings.Join(unpriced, ", "))
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("fetching prices from %s", strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// Lookup returns the USD price of token and the provider that supplied it.
+func Lookup(token Token) (float64, string, bool) {
+	pricesLock.RLock()
+	defer pricesLock.RUnlock()
+
+	q, ok := prices[token.normalize().Key()]
+	return q.price, q.source, ok
+}
+
+// Current returns every loaded price keyed by "id:<coingecko id>" or
+// "symbol:<SYMBOL>".
+func Current() map[string]float64 {
+	pricesLock.RLock()
+	defer pricesLock.RUnlock()
+
+	current := make(map[string]float64, len(prices))
+	for key, q := range prices {
+		current[key] = q.price
+	}
+	return current
+}
+
+func CalculateUSDValue(token Token, amount utils.Amount) utils.Amount {
+	if price, _, ok := Lookup(token); ok {
+		return amount.Mul(utils.AmountFromFloat(price))
+	}
+	return utils.Amount{}
+}
+
+func (t Token) normalize() Token {
+	return Token{ID: strings.ToLower(strings.TrimSpace(t.ID)), Symbol: strings.ToUpper(t.Symbol)}
+}
+
+// Key identifies the token in Current: "id:<coingecko id>" when the ID is
+// known, otherwise "symbol:<SYMBOL>".
+func (t Token) Key() string {
+	t = t.normalize()
+	if t.ID != "" {
+		return "id:" + t.ID
+	}
+	return "symbol:" + t.Symbol
+}
+
+func (t Token) String() string {
+	if t.ID != "" {
+		return fmt.Sprintf("%s (%s)", t.Symbol, t.ID)
+	}
+	return t.Symbol
+}
+
+func uniqueTokens(tokens []Token) []Token {
+	seen := make(map[string]bool)
+	var unique []Token
+	for _, token := range tokens {
+		token = token.normalize()
+		if token.ID == "" && token.Symbol == "" {
+			continue
+		}
+		if seen[token.Key()] {
+			continue
+		}
+		seen[token.Key()] = true
+		unique = append(unique, token)
+	}
+	sort.Slice(unique, func(i, j int) bool {
+		return unique[i].Key() < unique[j].Key()
+	})
+	return unique
+}
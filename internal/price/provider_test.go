@@ -0,0 +1,169 @@
+package price
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/anilcse/cosmoscope/internal/config"
+)
+
+type stubProvider struct {
+	name      string
+	prices    Prices
+	err       error
+	requested []Token
+}
+
+func (s *stubProvider) Name() string { return s.name }
+
+func (s *stubProvider) FetchPrices(tokens []Token) (Prices, error) {
+	s.requested = tokens
+	return s.prices, s.err
+}
+
+func TestInitializePricesPrefersIDsAndFallsThrough(t *testing.T) {
+	var gotIDs string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotIDs = r.URL.Query().Get("ids")
+		w.Write([]byte(`[
+			{"id":"usd-coin","symbol":"usdc","current_price":1.0},
+			{"id":"axlusdc","symbol":"usdc","current_price":0.99},
+			{"id":"tether","symbol":"usdt","current_price":1.001}
+		]`))
+	}))
+	defer server.Close()
+
+	fallback := &stubProvider{name: "static", prices: Prices{
+		ByID:     map[string]float64{},
+		BySymbol: map[string]float64{"STARS": 0.02},
+	}}
+
+	InitializePrices([]Provider{&CoinGecko{URL: server.URL + "?vs_currency=usd&ids=tether"}, fallback}, []Token{
+		{ID: "axlusdc", Symbol: "USDC"},
+		{ID: "usd-coin", Symbol: "USDC"},
+		{Symbol: "usdt"},
+		{Symbol: "STARS"},
+	})
+
+	if gotIDs != "axlusdc,tether,usd-coin" {
+		t.Errorf("ids query = %q, want axlusdc,tether,usd-coin", gotIDs)
+	}
+
+	tests := []struct {
+		token      Token
+		wantPrice  float64
+		wantSource string
+	}{
+		{Token{ID: "axlusdc", Symbol: "USDC"}, 0.99, "coingecko"},
+		{Token{ID: "usd-coin", Symbol: "USDC"}, 1.0, "coingecko"},
+		{Token{Symbol: "USDT"}, 1.001, "coingecko (symbol)"},
+		{Token{Symbol: "STARS"}, 0.02, "static (symbol)"},
+	}
+	for _, tt := range tests {
+		gotPrice, gotSource, ok := Lookup(tt.token)
+		if !ok || gotPrice != tt.wantPrice || gotSource != tt.wantSource {
+			t.Errorf("Lookup(%v) = %v, %q, %v, want %v, %q", tt.token, gotPrice, gotSource, ok, tt.wantPrice, tt.wantSource)
+		}
+	}
+}
+
+func TestInitializePricesSkipsFailingProviders(t *testing.T) {
+	failing := &stubProvider{name: "coinmarketcap", err: errors.New("rate limited")}
+	first := &stubProvider{name: "osmosis", prices: Prices{
+		ByID:     map[string]float64{},
+		BySymbol: map[string]float64{"ION": 300},
+	}}
+	last := &stubProvider{name: "static", prices: Prices{
+		ByID:     map[string]float64{"juno-network": 0.3},
+		BySymbol: map[string]float64{"ION": 1, "JUNO": 0.5},
+	}}
+
+	err := InitializePrices([]Provider{failing, first, last}, []Token{
+		{Symbol: "ION"},
+		{ID: "juno-network", Symbol: "JUNO"},
+		{Symbol: "NOPE"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "coinmarketcap: rate limited") {
+		t.Errorf("error = %v, want the failing provider", err)
+	}
+
+	if len(failing.requested) != 3 || len(first.requested) != 3 {
+		t.Errorf("a failing provider's tokens were not passed on: %v, %v", failing.requested, first.requested)
+	}
+	want := []Token{{ID: "juno-network", Symbol: "JUNO"}, {Symbol: "NOPE"}}
+	if !reflect.DeepEqual(last.requested, want) {
+		t.Errorf("last provider asked for %v, want only the unpriced %v", last.requested, want)
+	}
+
+	tests := []struct {
+		token      Token
+		wantPrice  float64
+		wantSource string
+	}{
+		{Token{Symbol: "ION"}, 300, "osmosis (symbol)"},
+		{Token{ID: "juno-network", Symbol: "JUNO"}, 0.3, "static"},
+	}
+	for _, tt := range tests {
+		gotPrice, gotSource, ok := Lookup(tt.token)
+		if !ok || gotPrice != tt.wantPrice || gotSource != tt.wantSource {
+			t.Errorf("Lookup(%v) = %v, %q, %v, want %v, %q", tt.token, gotPrice, gotSource, ok, tt.wantPrice, tt.wantSource)
+		}
+	}
+	if _, _, ok := Lookup(Token{Symbol: "NOPE"}); ok {
+		t.Error("NOPE has a price")
+	}
+}
+
+func TestNewProviders(t *testing.T) {
+	providers, err := NewProviders([]config.PriceProvider{
+		{Type: "CoinMarketCap", APIKey: "key"},
+		{Type: "coingecko"},
+		{Type: "osmosis"},
+		{Type: "static", File: "prices.json"},
+	}, "https://example.com/markets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, provider := range providers {
+		names = append(names, provider.Name())
+	}
+	if want := []string{"coinmarketcap", "coingecko", "osmosis", "static"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("providers = %v, want %v", names, want)
+	}
+	if got := providers[1].(*CoinGecko).URL; got != "https://example.com/markets" {
+		t.Errorf("coingecko URL = %q, want coingecko_uri", got)
+	}
+
+	defaults, err := NewProviders(nil, "https://example.com/markets")
+	if err != nil || len(defaults) != 1 || defaults[0].Name() != "coingecko" {
+		t.Errorf("default providers = %v, %v", defaults, err)
+	}
+	if _, err := NewProviders([]config.PriceProvider{{Type: "binance"}}, ""); err == nil {
+		t.Error("no error for an unknown provider")
+	}
+}
+
+func TestInitializePricesKeepsLastKnownPrices(t *testing.T) {
+	provider := &stubProvider{name: "static", prices: Prices{
+		ByID:     map[string]float64{"stride": 1.5},
+		BySymbol: map[string]float64{"LUNA": 0.4},
+	}}
+	tokens := []Token{{ID: "stride", Symbol: "STRD"}, {Symbol: "LUNA"}}
+	InitializePrices([]Provider{provider}, tokens)
+
+	// The next refresh fails for STRD and prices LUNA anew.
+	provider.prices = Prices{ByID: map[string]float64{}, BySymbol: map[string]float64{"LUNA": 0.5}}
+	InitializePrices([]Provider{provider, &stubProvider{name: "coingecko", err: errors.New("timeout")}}, tokens)
+
+	if price, source, ok := Lookup(Token{ID: "stride"}); !ok || price != 1.5 || source != "static" {
+		t.Errorf("STRD = %v, %q, %v, want the last known price", price, source, ok)
+	}
+	if price, _, ok := Lookup(Token{Symbol: "LUNA"}); !ok || price != 0.5 {
+		t.Errorf("LUNA = %v, %v, want the new price", price, ok)
+	}
+}
@@ -0,0 +1,32 @@
+package price
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStatic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prices.json")
+	if err := os.WriteFile(path, []byte(`{"USDC": 1, "Bitcoin": 65000}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	prices, err := (&Static{File: path}).FetchPrices(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prices.BySymbol["USDC"] != 1 || prices.ByID["usdc"] != 1 || prices.ByID["bitcoin"] != 65000 || prices.BySymbol["BITCOIN"] != 65000 {
+		t.Errorf("prices = %+v", prices)
+	}
+
+	if _, err := (&Static{File: filepath.Join(t.TempDir(), "missing.json")}).FetchPrices(nil); err == nil {
+		t.Error("no error for a missing file")
+	}
+	if err := os.WriteFile(path, []byte(`{"USDC": "one"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (&Static{File: path}).FetchPrices(nil); err == nil {
+		t.Error("no error for an invalid file")
+	}
+}
@@ -0,0 +1,154 @@
+package store
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/anilcse/cosmoscope/internal/portfolio"
+	bolt "go.etcd.io/bbolt"
+)
+
+var snapshotsBucket = []byte("snapshots")
+
+// Store persists portfolio snapshots in a local BoltDB file, keyed by the
+// snapshot timestamp so iteration is chronological.
+type Store struct {
+	db *bolt.DB
+}
+
+// DefaultPath is used when no snapshot database is configured.
+func DefaultPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "cosmoscope.db"
+	}
+	return filepath.Join(home, ".cosmoscope", "snapshots.db")
+}
+
+func Open(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("error creating snapshot directory: %v", err)
+	}
+
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("error opening snapshot database: %v", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(snapshotsBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error initializing snapshot database: %v", err)
+	}
+
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+func (s *Store) Save(snapshot portfolio.Snapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("error encoding snapshot: %v", err)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(snapshotsBucket).Put(timestampKey(snapshot.Timestamp), data)
+	})
+}
+
+// List returns the most recent limit snapshots in chronological order.
+// A limit of zero or less returns every snapshot.
+func (s *Store) List(limit int) ([]portfolio.Snapshot, error) {
+	var snapshots []portfolio.Snapshot
+	err := s.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(snapshotsBucket).Cursor()
+		for k, v := cursor.Last(); k != nil; k, v = cursor.Prev() {
+			if limit > 0 && len(snapshots) == limit {
+				break
+			}
+
+			var snapshot portfolio.Snapshot
+			if err := json.Unmarshal(v, &snapshot); err != nil {
+				return fmt.Errorf("error decoding snapshot: %v", err)
+			}
+			snapshots = append(snapshots, snapshot)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, j := 0, len(snapshots)-1; i < j; i, j = i+1, j-1 {
+		snapshots[i], snapshots[j] = snapshots[j], snapshots[i]
+	}
+	return snapshots, nil
+}
+
+// Get returns the snapshot with the given ID. "latest" and "previous" name
+// the most recent snapshot and the one before it.
+func (s *Store) Get(id string) (portfolio.Snapshot, error) {
+	switch id {
+	case "latest", "previous":
+		snapshots, err := s.List(2)
+		if err != nil {
+			return portfolio.Snapshot{}, err
+		}
+		index := len(snapshots) - 1
+		if id == "previous" {
+			index--
+		}
+		if index < 0 {
+			return portfolio.Snapshot{}, fmt.Errorf("no %s snapshot stored", id)
+		}
+		return snapshots[index], nil
+	}
+
+	second, err := portfolio.ParseSnapshotID(id)
+	if err != nil {
+		return portfolio.Snapshot{}, fmt.Errorf("invalid snapshot ID %q", id)
+	}
+
+	// IDs have one-second resolution, so look at every key in that second.
+	var matches [][]byte
+	err = s.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(snapshotsBucket).Cursor()
+		end := timestampKey(second.Add(time.Second))
+		for k, v := cursor.Seek(timestampKey(second)); k != nil && bytes.Compare(k, end) < 0; k, v = cursor.Next() {
+			matches = append(matches, append([]byte(nil), v...))
+		}
+		return nil
+	})
+	if err != nil {
+		return portfolio.Snapshot{}, err
+	}
+
+	switch len(matches) {
+	case 0:
+		return portfolio.Snapshot{}, fmt.Errorf("snapshot %s not found", id)
+	case 1:
+		var snapshot portfolio.Snapshot
+		if err := json.Unmarshal(matches[0], &snapshot); err != nil {
+			return portfolio.Snapshot{}, fmt.Errorf("error decoding snapshot: %v", err)
+		}
+		return snapshot, nil
+	}
+	return portfolio.Snapshot{}, fmt.Errorf("snapshot ID %s is ambiguous: %d snapshots were stored in that second", id, len(matches))
+}
+
+func timestampKey(t time.Time) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(t.UnixNano()))
+	return key
+}
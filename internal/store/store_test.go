@@ -0,0 +1,106 @@
+package store
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anilcse/cosmoscope/internal/portfolio"
+	"github.com/anilcse/cosmoscope/pkg/utils"
+)
+
+func TestSaveAndList(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "snapshots.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer db.Close()
+
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		err := db.Save(portfolio.Snapshot{
+			Timestamp: start.Add(time.Duration(i) * time.Hour),
+			Balances: []portfolio.Balance{{
+				Network:  "evmos-bank",
+				Token:    "EVMOS",
+				Amount:   utils.ParseAmount("123456789012345678901", 18),
+				USDValue: utils.ParseAmount("1", 0),
+			}},
+			Prices: map[string]float64{"id:evmos": 0.05},
+		})
+		if err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+
+	snapshots, err := db.List(2)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(snapshots) != 2 {
+		t.Fatalf("len(List()) = %d, want 2", len(snapshots))
+	}
+	if got := snapshots[0].ID(); got != "20240301-010000" {
+		t.Errorf("first snapshot = %v, want 20240301-010000", got)
+	}
+	if got := snapshots[1].Balances[0].Amount.String(); got != "123.456789012345678901" {
+		t.Errorf("stored amount = %v, want exact value", got)
+	}
+}
+
+func TestGet(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "snapshots.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer db.Close()
+
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		if err := db.Save(portfolio.Snapshot{Timestamp: start.Add(time.Duration(i) * time.Hour)}); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+
+	tests := map[string]string{
+		"20240301-010000": "20240301-010000",
+		"latest":          "20240301-020000",
+		"previous":        "20240301-010000",
+	}
+	for id, want := range tests {
+		snapshot, err := db.Get(id)
+		if err != nil {
+			t.Fatalf("Get(%q) error = %v", id, err)
+		}
+		if got := snapshot.ID(); got != want {
+			t.Errorf("Get(%q) = %v, want %v", id, got, want)
+		}
+	}
+
+	if _, err := db.Get("20240302-000000"); err == nil {
+		t.Error("Get() of a missing snapshot should fail")
+	}
+}
+
+func TestGetAmbiguousID(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "snapshots.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer db.Close()
+
+	second := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	for _, offset := range []time.Duration{0, 300 * time.Millisecond} {
+		if err := db.Save(portfolio.Snapshot{Timestamp: second.Add(offset)}); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+
+	if _, err := db.Get("20240301-000000"); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("Get() of a shared second = %v, want ambiguous ID error", err)
+	}
+	if _, err := db.Get("not-an-id"); err == nil {
+		t.Error("Get() of an invalid ID should fail")
+	}
+}
@@ -0,0 +1,224 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Amount is an exact decimal quantity: an integer number of base units
+// scaled by 10^-Exponent. The zero value is a valid zero amount.
+type Amount struct {
+	units    *big.Int
+	exponent int
+}
+
+// NewAmount returns units * 10^-exponent. A negative exponent is treated as 0.
+func NewAmount(units *big.Int, exponent int) Amount {
+	if units == nil {
+		return Amount{}
+	}
+	if exponent < 0 {
+		units = new(big.Int).Mul(units, pow10(-exponent))
+		exponent = 0
+	}
+	return Amount{units: new(big.Int).Set(units), exponent: exponent}
+}
+
+// maxExponent bounds the exponent accepted in scientific notation so a
+// malformed value cannot allocate an enormous number.
+const maxExponent = 1000
+
+// ParseDecimal parses a plain decimal string such as "12.3400" without
+// any loss of precision.
+func ParseDecimal(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Amount{}, fmt.Errorf("empty amount")
+	}
+
+	// Scientific notation scales the exact mantissa by the exponent.
+	if i := strings.IndexAny(s, "eE"); i != -1 {
+		mantissa, err := ParseDecimal(s[:i])
+		if err != nil {
+			return Amount{}, fmt.Errorf("invalid amount %q", s)
+		}
+		exp, err := strconv.Atoi(strings.TrimPrefix(s[i+1:], "+"))
+		if err != nil || exp > maxExponent || exp < -maxExponent {
+			return Amount{}, fmt.Errorf("invalid amount %q", s)
+		}
+		return NewAmount(mantissa.value(), mantissa.exponent-exp).normalize(), nil
+	}
+
+	intPart, fracPart := s, ""
+	if i := strings.IndexByte(s, '.'); i != -1 {
+		intPart, fracPart = s[:i], s[i+1:]
+	}
+
+	units, ok := new(big.Int).SetString(intPart+fracPart, 10)
+	if !ok {
+		return Amount{}, fmt.Errorf("invalid amount %q", s)
+	}
+	return Amount{units: units, exponent: len(fracPart)}.normalize(), nil
+}
+
+// AmountFromFloat converts f using its shortest decimal representation.
+// It is meant for prices and config values that are already floats.
+func AmountFromFloat(f float64) Amount {
+	a, err := ParseDecimal(big.NewFloat(f).Text('f', -1))
+	if err != nil {
+		return Amount{}
+	}
+	return a
+}
+
+// Units returns the amount expressed in base units at the given number of
+// decimals, truncating any finer precision.
+func (a Amount) Units(decimals int) *big.Int {
+	return a.rescale(decimals).value()
+}
+
+// Exponent returns the number of decimal places carried by the amount.
+func (a Amount) Exponent() int {
+	return a.exponent
+}
+
+func (a Amount) Add(b Amount) Amount {
+	exp := maxInt(a.exponent, b.exponent)
+	sum := new(big.Int).Add(a.rescale(exp).value(), b.rescale(exp).value())
+	return Amount{units: sum, exponent: exp}.normalize()
+}
+
+func (a Amount) Sub(b Amount) Amount {
+	return a.Add(b.Neg())
+}
+
+func (a Amount) Neg() Amount {
+	return Amount{units: new(big.Int).Neg(a.value()), exponent: a.exponent}
+}
+
+func (a Amount) Mul(b Amount) Amount {
+	product := new(big.Int).Mul(a.value(), b.value())
+	return Amount{units: product, exponent: a.exponent + b.exponent}.normalize()
+}
+
+// Cmp compares a and b and returns -1, 0 or +1.
+func (a Amount) Cmp(b Amount) int {
+	exp := maxInt(a.exponent, b.exponent)
+	return a.rescale(exp).value().Cmp(b.rescale(exp).value())
+}
+
+func (a Amount) Sign() int {
+	return a.value().Sign()
+}
+
+func (a Amount) IsZero() bool {
+	return a.Sign() == 0
+}
+
+// Float64 returns the nearest float64 value. It should only be used when
+// rendering or when comparing against float thresholds.
+func (a Amount) Float64() float64 {
+	f, _ := a.rat().Float64()
+	return f
+}
+
+// String returns the exact decimal representation without trailing zeros.
+func (a Amount) String() string {
+	a = a.normalize()
+	if a.exponent == 0 {
+		return a.value().String()
+	}
+
+	digits := new(big.Int).Abs(a.value()).String()
+	if len(digits) <= a.exponent {
+		digits = strings.Repeat("0", a.exponent-len(digits)+1) + digits
+	}
+	point := len(digits) - a.exponent
+
+	sign := ""
+	if a.Sign() < 0 {
+		sign = "-"
+	}
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// MarshalJSON encodes the amount as a decimal string so no precision is lost.
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+// UnmarshalJSON accepts either a JSON number or a decimal string.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "null" {
+		*a = Amount{}
+		return nil
+	}
+
+	parsed, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+func (a Amount) value() *big.Int {
+	if a.units == nil {
+		return new(big.Int)
+	}
+	return a.units
+}
+
+func (a Amount) rat() *big.Rat {
+	return new(big.Rat).SetFrac(a.value(), pow10(a.exponent))
+}
+
+// rescale returns the amount with exactly exp decimal places, truncating
+// toward zero when precision has to be dropped.
+func (a Amount) rescale(exp int) Amount {
+	switch {
+	case exp == a.exponent:
+		return a
+	case exp > a.exponent:
+		units := new(big.Int).Mul(a.value(), pow10(exp-a.exponent))
+		return Amount{units: units, exponent: exp}
+	default:
+		units := new(big.Int).Quo(a.value(), pow10(a.exponent-exp))
+		return Amount{units: units, exponent: exp}
+	}
+}
+
+// normalize strips trailing zero digits from the fractional part.
+func (a Amount) normalize() Amount {
+	units := new(big.Int).Set(a.value())
+	exp := a.exponent
+	ten := big.NewInt(10)
+	rem := new(big.Int)
+	for exp > 0 && units.Sign() != 0 {
+		q, r := new(big.Int).QuoRem(units, ten, rem)
+		if r.Sign() != 0 {
+			break
+		}
+		units = q
+		exp--
+	}
+	if units.Sign() == 0 {
+		exp = 0
+	}
+	return Amount{units: units, exponent: exp}
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
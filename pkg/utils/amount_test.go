@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		decimals int
+		want     string
+	}{
+		{
+			name:     "18 decimal token keeps every digit",
+			amount:   "123456789012345678901234567",
+			decimals: 18,
+			want:     "123456789.012345678901234567",
+		},
+		{
+			name:     "dec coin reward",
+			amount:   "1234.567890000000000000",
+			decimals: 6,
+			want:     "0.00123456789",
+		},
+		{
+			name:     "sub-unit amount",
+			amount:   "5",
+			decimals: 6,
+			want:     "0.000005",
+		},
+		{
+			name:     "invalid amount",
+			amount:   "abc",
+			decimals: 6,
+			want:     "0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseAmount(tt.amount, tt.decimals).String()
+			if got != tt.want {
+				t.Errorf("ParseAmount() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAmountArithmetic(t *testing.T) {
+	a := ParseAmount("1000000000000000001", 18)
+	b := ParseAmount("2500000", 6)
+
+	if got := a.Add(b).String(); got != "3.500000000000000001" {
+		t.Errorf("Add() = %v", got)
+	}
+	if got := b.Sub(a).String(); got != "1.499999999999999999" {
+		t.Errorf("Sub() = %v", got)
+	}
+	if got := b.Mul(AmountFromFloat(1.1)).String(); got != "2.75" {
+		t.Errorf("Mul() = %v", got)
+	}
+	if a.Cmp(b) >= 0 || b.Cmp(a) <= 0 || a.Cmp(a) != 0 {
+		t.Errorf("Cmp() ordering is wrong")
+	}
+	if got := NewAmount(big.NewInt(15), 1).Units(3); got.String() != "1500" {
+		t.Errorf("Units() = %v", got)
+	}
+	if !(Amount{}).IsZero() {
+		t.Errorf("zero value should be zero")
+	}
+}
+
+func TestParseDecimalScientific(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"1.5e3", "1500"},
+		{"1E+2", "100"},
+		{"1.234e-25", "0.0000000000000000000000001234"},
+		{"-2.5e-1", "-0.25"},
+	}
+	for _, tt := range tests {
+		got, err := ParseDecimal(tt.in)
+		if err != nil || got.String() != tt.want {
+			t.Errorf("ParseDecimal(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+
+	for _, in := range []string{"1e", "e5", "1e99999"} {
+		if _, err := ParseDecimal(in); err == nil {
+			t.Errorf("ParseDecimal(%q) should fail", in)
+		}
+	}
+}
@@ -2,9 +2,7 @@ package utils
 
 import (
 	"fmt"
-	"math"
 	"math/big"
-	"strconv"
 )
 
 func FormatAmount(amount float64, decimals int) string {
@@ -28,18 +26,16 @@ func FormatAmount(amount float64, decimals int) string {
 	return fmt.Sprintf(formatStr, amount)
 }
 
-func ParseAmount(amount string, decimals int) float64 {
-	val, err := strconv.ParseFloat(amount, 64)
+// ParseAmount converts a base-unit amount string (which may itself carry a
+// fractional part, as with DecCoins) into an exact Amount scaled by decimals.
+func ParseAmount(amount string, decimals int) Amount {
+	val, err := ParseDecimal(amount)
 	if err != nil {
-		return 0
+		return Amount{}
 	}
-	return val / math.Pow10(decimals)
+	return val.Mul(NewAmount(big.NewInt(1), decimals))
 }
 
-func ParseWeiToEther(wei *big.Int) float64 {
-	f := new(big.Float)
-	f.SetString(wei.String())
-	ethValue := new(big.Float).Quo(f, big.NewFloat(1e18))
-	result, _ := ethValue.Float64()
-	return result
+func ParseWeiToEther(wei *big.Int) Amount {
+	return NewAmount(wei, 18)
 }